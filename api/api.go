@@ -0,0 +1,80 @@
+package api
+
+import (
+	"github.com/go-martini/martini"
+	"github.com/martini-contrib/binding"
+	"github.com/obieq/rva-devops-api/controllers"
+	"github.com/obieq/rva-devops-api/models"
+	"github.com/obieq/rva-devops-api/resources"
+)
+
+// Register => exposes a goar model as a JSON API resource, wiring the index, show, create,
+// update (PUT), patch, delete and history routes under resources.API_PATH + "/" + resourceType
+//
+//	api.Register(m, "automobiles", models.Automobile{}, resources.Automobile{})
+func Register(router martini.Router, resourceType string, model, resource interface{}) *controllers.ResourceController {
+	c := controllers.NewResourceController(resourceType, model, resource)
+	controllers.AddController(c)
+
+	collection := resources.API_PATH + "/" + resourceType
+	member := collection + "/:id"
+
+	router.Get(collection, c.Index)
+	router.Get(member, c.Show)
+	router.Post(collection, binding.Json(resources.JsonApiRequest{}), controllers.HandleBindingErrors, c.Create)
+	router.Put(member, binding.Json(resources.JsonApiRequest{}), controllers.HandleBindingErrors, c.Update)
+	router.Patch(member, binding.Json(resources.JsonApiPatchRequest{}), controllers.HandleBindingErrors, c.Patch)
+	router.Delete(member, c.Delete)
+	router.Get(member+"/history", c.History)
+
+	return c
+}
+
+// RegisterAPIKeys => endpoints to issue (POST), list (GET) and revoke (DELETE) api keys
+func RegisterAPIKeys(router martini.Router) *controllers.ResourceController {
+	c := controllers.NewResourceController(resources.API_KEY_RESOURCE_TYPE, models.APIKey{}, resources.APIKey{})
+	controllers.AddController(c)
+
+	collection := resources.API_PATH + "/" + c.Type
+	member := collection + "/:id"
+
+	router.Get(collection, c.Index)
+	router.Get(member, c.Show)
+	router.Post(collection, binding.Json(resources.JsonApiRequest{}), controllers.HandleBindingErrors, c.IssueAPIKey)
+	router.Delete(member, c.RevokeAPIKey)
+
+	return c
+}
+
+// BelongsTo => relates each child to one parent and each parent to many children, e.g.
+//
+//	api.BelongsTo(m, automobiles, "owner", owners, "automobiles")
+//
+// exposes an automobile's "owner" and an owner's "automobiles" as relationships, each with a
+// relationship route (/:id/relationships/:name) and a related resource route (/:id/:name)
+func BelongsTo(router martini.Router, child *controllers.ResourceController, name string, parent *controllers.ResourceController, inverse string) {
+	toOne := &controllers.Relationship{Name: name, Related: parent, Inverse: inverse}
+	toMany := &controllers.Relationship{Name: inverse, Related: child, ToMany: true, Inverse: name}
+
+	child.AddRelationship(toOne)
+	parent.AddRelationship(toMany)
+
+	relationshipRoutes(router, child, toOne)
+	relationshipRoutes(router, parent, toMany)
+}
+
+// relationshipRoutes => wires a relationship's routes
+// NOTE: per JSON API, members can only be added (POST) or removed (DELETE) on to-many relationships
+func relationshipRoutes(router martini.Router, c *controllers.ResourceController, rel *controllers.Relationship) {
+	member := resources.API_PATH + "/" + c.Type + "/:id"
+	relationship := member + "/relationships/" + rel.Name
+
+	router.Get(member+"/"+rel.Name, c.ShowRelated(rel))
+	router.Get(relationship, c.ShowRelationship(rel))
+	router.Patch(relationship, binding.Json(resources.JsonApiRelationshipRequest{}), controllers.HandleBindingErrors, c.UpdateRelationship(rel))
+
+	if rel.ToMany {
+		router.Post(relationship, binding.Json(resources.JsonApiRelationshipRequest{}), controllers.HandleBindingErrors, c.AddToRelationship(rel))
+		router.Delete(relationship, binding.Json(resources.JsonApiRelationshipRequest{}), controllers.HandleBindingErrors, c.RemoveFromRelationship(rel))
+	}
+}
@@ -0,0 +1,394 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/go-martini/martini"
+	"github.com/martini-contrib/render"
+	"github.com/obieq/rva-devops-api/auth"
+	"github.com/obieq/rva-devops-api/db"
+	"github.com/obieq/rva-devops-api/db/memory"
+	"github.com/obieq/rva-devops-api/middleware"
+	"github.com/obieq/rva-devops-api/models"
+	"github.com/obieq/rva-devops-api/resources"
+)
+
+var (
+	handlerOnce sync.Once
+	handler     http.Handler
+)
+
+// server => the resource routes, wired as in server.go but without authentication, against an empty memory store
+// NOTE: controllers can only be registered once, so the tests share the routes
+func server() http.Handler {
+	db.Use(memory.NewStore())
+	handlerOnce.Do(func() { handler = routes() })
+	return handler
+}
+
+func routes() http.Handler {
+	router := martini.NewRouter()
+	m := &martini.ClassicMartini{Martini: martini.New(), Router: router}
+	m.Use(middleware.Recovery())
+	m.MapTo(router, (*martini.Routes)(nil))
+	m.Action(router.Handle)
+	router.NotFound(middleware.NotFound)
+
+	m.Use(middleware.MediaTypes(middleware.JsonApiMediaType{}))
+	m.Use(render.Renderer())
+	m.Use(middleware.APIRoot("http://example.com"))
+	m.Map(&auth.Principal{KeyID: "k1", Name: "tester", Role: models.ADMIN_ROLE})
+
+	automobiles := Register(m, resources.AUTOMOBILE_RESOURCE_TYPE, models.Automobile{}, resources.Automobile{})
+	owners := Register(m, resources.OWNER_RESOURCE_TYPE, models.Owner{}, resources.Owner{})
+	BelongsTo(m, automobiles, "owner", owners, resources.AUTOMOBILE_RESOURCE_TYPE)
+
+	return m
+}
+
+type client struct {
+	t       *testing.T
+	handler http.Handler
+}
+
+// do => sends a request with a JSON API body (if any) and extra headers, as "Name: value" pairs
+func (c client) do(method, path, body string, headers ...string) *httptest.ResponseRecorder {
+	req, err := http.NewRequest(method, path, strings.NewReader(body))
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	req.RemoteAddr = "192.0.2.1:1234"
+	req.Header.Set("Accept", middleware.JSON_API_MEDIA_TYPE)
+	if body != "" {
+		req.Header.Set("Content-Type", middleware.JSON_API_MEDIA_TYPE)
+	}
+	for _, header := range headers {
+		i := strings.Index(header, ":")
+		req.Header.Set(header[:i], strings.TrimSpace(header[i+1:]))
+	}
+
+	res := httptest.NewRecorder()
+	c.handler.ServeHTTP(res, req)
+	return res
+}
+
+// expect => fails the test unless res has status and, when v is given, decodes its body into v
+func (c client) expect(res *httptest.ResponseRecorder, status int, v interface{}) {
+	if res.Code != status {
+		c.t.Fatalf("status %d, want %d: %s", res.Code, status, res.Body.String())
+	}
+	if v != nil {
+		if err := json.Unmarshal(res.Body.Bytes(), v); err != nil {
+			c.t.Fatalf("decoding %s: %v", res.Body.String(), err)
+		}
+	}
+}
+
+type document struct {
+	Data  json.RawMessage `json:"data"`
+	Links struct {
+		Next string `json:"next"`
+	} `json:"links"`
+	Errors []resources.Error `json:"errors"`
+}
+
+type automobile struct {
+	Type  string `json:"type"`
+	ID    string `json:"id"`
+	Year  int    `json:"year"`
+	Make  string `json:"make"`
+	Model string `json:"model"`
+}
+
+func (d document) automobile(t *testing.T) (a automobile) {
+	if err := json.Unmarshal(d.Data, &a); err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
+
+func TestResourceRoutes(t *testing.T) {
+	c := client{t, server()}
+	collection := "/api/v1/automobiles"
+
+	// create
+	var created document
+	res := c.do("POST", collection, `{"data":{"type":"automobiles","make":"Ford","model":"Focus","year":2012}}`)
+	c.expect(res, 201, &created)
+	focus := created.automobile(t)
+	if focus.ID == "" || focus.Make != "Ford" || focus.Year != 2012 {
+		t.Fatalf("created %+v", focus)
+	}
+	if contentType := res.Header().Get("Content-Type"); contentType != middleware.JSON_API_MEDIA_TYPE {
+		t.Errorf("Content-Type %q, want %q", contentType, middleware.JSON_API_MEDIA_TYPE)
+	}
+	if location := res.Header().Get("Location"); location != "http://example.com"+collection+"/"+focus.ID {
+		t.Errorf("Location %q", location)
+	}
+	member := collection + "/" + focus.ID
+
+	c.expect(c.do("POST", collection, `{"data":{"type":"automobiles","make":"Audi","model":"A4","year":2005}}`), 201, nil)
+
+	// invalid attributes and mismatched types
+	var invalid document
+	c.expect(c.do("POST", collection, `{"data":{"type":"automobiles","model":"A4","year":2005}}`), 422, &invalid)
+	if len(invalid.Errors) != 1 || invalid.Errors[0].Source == nil || invalid.Errors[0].Source.Pointer != "/data/attributes/make" {
+		t.Errorf("422 errors %+v, want one pointing at make", invalid.Errors)
+	}
+	c.expect(c.do("POST", collection, `{"data":{"type":"owners","make":"Audi","model":"A4","year":2005}}`), 409, nil)
+
+	// show, with conditional requests
+	res = c.do("GET", member, "")
+	c.expect(res, 200, nil)
+	etag := res.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("show sent no ETag")
+	}
+	c.expect(c.do("GET", member, "", "If-None-Match: "+etag), 304, nil)
+	c.expect(c.do("GET", collection+"/missing", ""), 404, nil)
+
+	// index, filtered and sorted
+	var index document
+	c.expect(c.do("GET", collection+"?filter[year][gte]=2010", ""), 200, &index)
+	var found []automobile
+	json.Unmarshal(index.Data, &found)
+	if len(found) != 1 || found[0].ID != focus.ID {
+		t.Errorf("filter[year][gte]=2010 found %+v, want only the Focus", found)
+	}
+	c.expect(c.do("GET", collection+"?sort=-year", ""), 200, &index)
+	found = nil
+	json.Unmarshal(index.Data, &found)
+	if len(found) != 2 || found[0].Year != 2012 || found[1].Year != 2005 {
+		t.Errorf("sort=-year found %+v, want the Focus then the A4", found)
+	}
+	c.expect(c.do("GET", collection+"?filter[color]=red", ""), 400, nil)
+
+	// patch, guarded by If-Match
+	patch := `{"data":{"type":"automobiles","id":"` + focus.ID + `","year":2013}}`
+	c.expect(c.do("PATCH", member, patch, `If-Match: "stale"`), 412, nil)
+	var patched document
+	res = c.do("PATCH", member, patch, "If-Match: "+etag)
+	c.expect(res, 200, &patched)
+	if a := patched.automobile(t); a.Year != 2013 || a.Make != "Ford" {
+		t.Errorf("patched %+v, want year 2013 and the other attributes kept", a)
+	}
+	if res.Header().Get("ETag") == etag {
+		t.Error("the ETag did not change with the patch")
+	}
+	c.expect(c.do("PATCH", member, patch, "If-Match: "+etag), 412, nil)
+
+	// put replaces every attribute
+	var put document
+	c.expect(c.do("PUT", member, `{"data":{"type":"automobiles","id":"`+focus.ID+`","make":"Ford","model":"Fiesta","year":2014}}`), 200, &put)
+	if a := put.automobile(t); a.Model != "Fiesta" || a.Year != 2014 {
+		t.Errorf("put %+v", a)
+	}
+
+	// relationships
+	var owner document
+	c.expect(c.do("POST", "/api/v1/owners", `{"data":{"type":"owners","first-name":"Ada","last-name":"Lovelace"}}`), 201, &owner)
+	var ownerID struct {
+		ID string `json:"id"`
+	}
+	json.Unmarshal(owner.Data, &ownerID)
+	c.expect(c.do("PATCH", member+"/relationships/owner", `{"data":{"type":"owners","id":"`+ownerID.ID+`"}}`), 204, nil)
+	var owned document
+	c.expect(c.do("GET", "/api/v1/owners/"+ownerID.ID+"/automobiles", ""), 200, &owned)
+	found = nil
+	json.Unmarshal(owned.Data, &found)
+	if len(found) != 1 || found[0].ID != focus.ID {
+		t.Errorf("owner's automobiles %+v, want the Focus", found)
+	}
+
+	// delete
+	c.expect(c.do("DELETE", member, ""), 204, nil)
+	c.expect(c.do("GET", member, ""), 404, nil)
+	c.expect(c.do("DELETE", member, ""), 404, nil)
+}
+
+func TestUnroutedRequests(t *testing.T) {
+	c := client{t, server()}
+
+	var notFound document
+	c.expect(c.do("GET", "/api/v1/bicycles", ""), 404, &notFound)
+	if len(notFound.Errors) != 1 || notFound.Errors[0].Status != "404" {
+		t.Errorf("404 errors %+v", notFound.Errors)
+	}
+
+	res := c.do("PUT", "/api/v1/automobiles", `{"data":{"type":"automobiles"}}`)
+	c.expect(res, 405, nil)
+	if allow := res.Header().Get("Allow"); allow != "GET, HEAD, POST" {
+		t.Errorf("Allow %q, want GET, HEAD, POST", allow)
+	}
+}
+
+type included struct {
+	Data struct {
+		ID            string `json:"id"`
+		Relationships struct {
+			Owner struct {
+				Data *struct {
+					Type string `json:"type"`
+					ID   string `json:"id"`
+				} `json:"data"`
+			} `json:"owner"`
+		} `json:"relationships"`
+	} `json:"data"`
+	Included []struct {
+		Type string `json:"type"`
+		ID   string `json:"id"`
+	} `json:"included"`
+}
+
+// keys => "type/id" of each included resource, in order
+func (d included) keys() []string {
+	keys := make([]string, len(d.Included))
+	for i, resource := range d.Included {
+		keys[i] = resource.Type + "/" + resource.ID
+	}
+	return keys
+}
+
+func TestInclude(t *testing.T) {
+	c := client{t, server()}
+
+	create := func(path, body string) string {
+		var created struct {
+			Data struct {
+				ID string `json:"id"`
+			} `json:"data"`
+		}
+		c.expect(c.do("POST", path, body), 201, &created)
+		return created.Data.ID
+	}
+	automobile := func(make string, year int, owner string) string {
+		id := create("/api/v1/automobiles", fmt.Sprintf(`{"data":{"type":"automobiles","make":%q,"model":"x","year":%d}}`, make, year))
+		c.expect(c.do("PATCH", "/api/v1/automobiles/"+id+"/relationships/owner", `{"data":{"type":"owners","id":"`+owner+`"}}`), 204, nil)
+		return id
+	}
+
+	ada := create("/api/v1/owners", `{"data":{"type":"owners","first-name":"Ada","last-name":"Lovelace"}}`)
+	alan := create("/api/v1/owners", `{"data":{"type":"owners","first-name":"Alan","last-name":"Turing"}}`)
+	focus := automobile("Ford", 2001, ada)
+	a4 := automobile("Audi", 2002, ada)
+	fiesta := automobile("Ford", 2003, alan)
+	tt := automobile("Audi", 2004, ada)
+
+	// show: the owner, then its other automobiles in the order they were related; never the primary resource
+	var show included
+	c.expect(c.do("GET", "/api/v1/automobiles/"+focus+"?include=owner,owner.automobiles", ""), 200, &show)
+	if want := []string{"owners/" + ada, "automobiles/" + a4, "automobiles/" + tt}; !reflect.DeepEqual(show.keys(), want) {
+		t.Errorf("show included %v, want %v", show.keys(), want)
+	}
+	if owner := show.Data.Relationships.Owner.Data; owner == nil || owner.Type != "owners" || owner.ID != ada {
+		t.Errorf("show owner linkage %+v, want owners/%s", owner, ada)
+	}
+
+	// index: each level in the order of the primary data, each resource once, and primary data not repeated
+	want := []string{"owners/" + ada, "owners/" + alan, "automobiles/" + a4, "automobiles/" + tt}
+	for i := 0; i < 3; i++ {
+		var index struct {
+			Data []struct {
+				ID string `json:"id"`
+			} `json:"data"`
+			included
+		}
+		c.expect(c.do("GET", "/api/v1/automobiles?filter[make]=Ford&sort=year&include=owner,owner.automobiles", ""), 200, &index)
+		if len(index.Data) != 2 || index.Data[0].ID != focus || index.Data[1].ID != fiesta {
+			t.Fatalf("index data %+v, want the Focus and the Fiesta", index.Data)
+		}
+		if !reflect.DeepEqual(index.keys(), want) {
+			t.Errorf("index included %v, want %v", index.keys(), want)
+		}
+	}
+
+	c.expect(c.do("GET", "/api/v1/automobiles/"+focus+"?include=driver", ""), 400, nil)
+}
+
+type change struct {
+	ID        string `json:"id"`
+	Action    string `json:"action"`
+	Principal string `json:"principal"`
+}
+
+// history => every change reachable from path by following next links, and how many pages that took
+func (c client) history(path string) (changes []change, pages int) {
+	for next := path; next != ""; pages++ {
+		var page document
+		c.expect(c.do("GET", strings.TrimPrefix(next, "http://example.com"), ""), 200, &page)
+		var batch []change
+		if err := json.Unmarshal(page.Data, &batch); err != nil {
+			c.t.Fatal(err)
+		}
+		changes = append(changes, batch...)
+		next = page.Links.Next
+	}
+	return changes, pages
+}
+
+func TestHistory(t *testing.T) {
+	c := client{t, server()}
+
+	var created document
+	c.expect(c.do("POST", "/api/v1/automobiles", `{"data":{"type":"automobiles","make":"Ford","model":"Focus","year":2012}}`), 201, &created)
+	member := "/api/v1/automobiles/" + created.automobile(t).ID
+	for year := 2013; year <= 2014; year++ {
+		c.expect(c.do("PATCH", member, fmt.Sprintf(`{"data":{"type":"automobiles","id":"%s","year":%d}}`, created.automobile(t).ID, year)), 200, nil)
+	}
+	c.expect(c.do("DELETE", member, ""), 204, nil)
+
+	// history outlives the resource, newest first
+	changes, _ := c.history(member + "/history")
+	var actions []string
+	for _, change := range changes {
+		actions = append(actions, change.Action)
+		if change.Principal != "tester" {
+			t.Errorf("change %s by %q, want tester", change.Action, change.Principal)
+		}
+	}
+	if got := strings.Join(actions, ","); got != "delete,update,update,create" {
+		t.Errorf("history %s, want delete,update,update,create", got)
+	}
+
+	paged, pages := c.history(member + "/history?page[size]=1")
+	if !reflect.DeepEqual(paged, changes) || pages != 4 {
+		t.Errorf("paging by 1 took %d pages to %+v, want 4 to %+v", pages, paged, changes)
+	}
+
+	c.expect(c.do("GET", member+"/history?page[before]=soon", ""), 400, nil)
+}
+
+// TestHistoryPaging => pages of the largest size reach every change, however many share a millisecond with the
+// previous page's last
+func TestHistoryPaging(t *testing.T) {
+	c := client{t, server()}
+
+	var created document
+	c.expect(c.do("POST", "/api/v1/automobiles", `{"data":{"type":"automobiles","make":"Ford","model":"Focus","year":2012}}`), 201, &created)
+	id := created.automobile(t).ID
+	for i := 0; i < 250; i++ {
+		c.expect(c.do("PATCH", "/api/v1/automobiles/"+id, fmt.Sprintf(`{"data":{"type":"automobiles","id":"%s","year":%d}}`, id, 1900+i)), 200, nil)
+	}
+
+	changes, pages := c.history("/api/v1/automobiles/" + id + "/history?page[size]=100")
+	if len(changes) != 251 || pages != 3 {
+		t.Fatalf("paged through %d changes in %d pages, want 251 in 3", len(changes), pages)
+	}
+	seen := make(map[string]bool)
+	for _, change := range changes {
+		if seen[change.ID] {
+			t.Errorf("change %s on more than one page", change.ID)
+		}
+		seen[change.ID] = true
+	}
+	if changes[250].Action != "create" {
+		t.Errorf("oldest change %+v, want the create", changes[250])
+	}
+}
@@ -0,0 +1,204 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/obieq/rva-devops-api/db"
+	"github.com/obieq/rva-devops-api/models"
+)
+
+// ADMIN_PRINCIPAL => name of the principal authenticated by the configured admin key
+const ADMIN_PRINCIPAL string = "admin"
+
+// CACHE_TTL => how long a verified key is trusted before it is looked up again
+// NOTE: bounds how long a key revoked through another server instance keeps working
+const CACHE_TTL = 30 * time.Second
+
+var (
+	// ErrInvalidKey => the token is malformed, unknown, revoked or does not match
+	ErrInvalidKey = errors.New("auth: invalid api key")
+)
+
+// Principal => the caller a request was authenticated as
+type Principal struct {
+	// KeyID => id of the api key used; "" for the admin key
+	KeyID string
+	Name  string
+	// Role => one of models.READER_ROLE, models.EDITOR_ROLE or models.ADMIN_ROLE
+	Role string
+}
+
+// roleRanks => roles by privilege; each role may do everything the roles ranked below it may
+var roleRanks = map[string]int{models.READER_ROLE: 1, models.EDITOR_ROLE: 2, models.ADMIN_ROLE: 3}
+
+// MayGrant => whether the principal may issue keys with role ("" meaning models.READER_ROLE), i.e. role does
+// not outrank its own
+// NOTE: unknown roles are left to api key validation
+func (p *Principal) MayGrant(role string) bool {
+	if role == "" {
+		role = models.READER_ROLE
+	}
+	rank, known := roleRanks[strings.ToLower(role)]
+	return !known || rank <= roleRanks[p.Role]
+}
+
+type cached struct {
+	principal *Principal
+	expires   time.Time
+}
+
+var (
+	adminMu   sync.RWMutex
+	adminHash []byte
+
+	cacheMu sync.Mutex
+	cache   = make(map[string]cached) // token hash => principal
+)
+
+// SetAdminKey => the key that authenticates as the admin principal, e.g. to issue the first api keys; "" disables it
+func SetAdminKey(key string) {
+	adminMu.Lock()
+	defer adminMu.Unlock()
+
+	if key == "" {
+		adminHash = nil
+	} else {
+		adminHash = hash(key)
+	}
+}
+
+// Issue => creates a key named name with role (models.READER_ROLE if empty) and returns it with its token, "<id>.<secret>"
+// NOTE: the token cannot be recovered later; only the hash of its secret is stored. When the key fails
+// validation, the token is empty and the key carries the validation errors
+func Issue(name, role string) (*models.APIKey, string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, "", err
+	}
+	encoded := hex.EncodeToString(secret)
+
+	if role == "" {
+		role = models.READER_ROLE
+	}
+
+	key := models.APIKey{Name: name, Role: role, Hash: hex.EncodeToString(hash(encoded))}.ToActiveRecord()
+	success, err := key.Save()
+	if !success {
+		return key, "", err
+	}
+
+	return key, key.ID + "." + encoded, nil
+}
+
+// Revoke => marks the key identified by id as revoked
+func Revoke(id string) (*models.APIKey, error) {
+	result, err := models.APIKey{}.ToActiveRecord().Find(id)
+	if err != nil {
+		return nil, err
+	}
+	key := result.(*models.APIKey)
+
+	if !key.Revoked() {
+		now := time.Now().UTC()
+		key.RevokedAt = &now
+		if _, err := key.Save(); err != nil {
+			return nil, err
+		}
+	}
+
+	forget(key.ID)
+	return key, nil
+}
+
+// Authenticate => the principal a token belongs to
+// NOTE: backend failures are returned as is, so callers can tell an outage from a bad key
+func Authenticate(token string) (*Principal, error) {
+	tokenHash := hash(token)
+
+	adminMu.RLock()
+	admin := adminHash != nil && subtle.ConstantTimeCompare(adminHash, tokenHash) == 1
+	adminMu.RUnlock()
+	if admin {
+		return &Principal{Name: ADMIN_PRINCIPAL, Role: models.ADMIN_ROLE}, nil
+	}
+
+	cacheKey := string(tokenHash)
+	if principal := lookup(cacheKey); principal != nil {
+		return principal, nil
+	}
+
+	i := strings.Index(token, ".")
+	if i <= 0 {
+		return nil, ErrInvalidKey
+	}
+	id, secret := token[:i], token[i+1:]
+
+	result, err := models.APIKey{}.ToActiveRecord().Find(id)
+	if db.KindOf(err) == db.NotFound {
+		return nil, ErrInvalidKey
+	} else if err != nil {
+		return nil, err
+	}
+	key := result.(*models.APIKey)
+
+	stored, err := hex.DecodeString(key.Hash)
+	if err != nil || key.Revoked() || subtle.ConstantTimeCompare(stored, hash(secret)) != 1 {
+		return nil, ErrInvalidKey
+	}
+
+	// keys issued before roles existed carry none
+	role := key.Role
+	if role == "" {
+		role = models.READER_ROLE
+	}
+
+	principal := &Principal{KeyID: key.ID, Name: key.Name, Role: role}
+	remember(cacheKey, principal)
+	return principal, nil
+}
+
+func hash(s string) []byte {
+	sum := sha256.Sum256([]byte(s))
+	return sum[:]
+}
+
+func lookup(cacheKey string) *Principal {
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+
+	entry, ok := cache[cacheKey]
+	if !ok {
+		return nil
+	}
+	if time.Now().After(entry.expires) {
+		delete(cache, cacheKey)
+		return nil
+	}
+	return entry.principal
+}
+
+func remember(cacheKey string, principal *Principal) {
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+
+	cache[cacheKey] = cached{principal: principal, expires: time.Now().Add(CACHE_TTL)}
+}
+
+// forget => drops the cached principals of a key
+func forget(keyID string) {
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+
+	for k, entry := range cache {
+		if entry.principal.KeyID == keyID {
+			delete(cache, k)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package auth
+
+import (
+	"testing"
+
+	"github.com/obieq/rva-devops-api/models"
+)
+
+func TestMayGrant(t *testing.T) {
+	tests := []struct {
+		role, grant string
+		want        bool
+	}{
+		{models.READER_ROLE, "", true},
+		{models.READER_ROLE, models.READER_ROLE, true},
+		{models.READER_ROLE, models.EDITOR_ROLE, false},
+		{models.EDITOR_ROLE, models.EDITOR_ROLE, true},
+		{models.EDITOR_ROLE, models.ADMIN_ROLE, false},
+		{models.EDITOR_ROLE, "ADMIN", false},
+		{models.ADMIN_ROLE, models.ADMIN_ROLE, true},
+	}
+
+	for _, test := range tests {
+		principal := &Principal{Name: "test", Role: test.role}
+		if got := principal.MayGrant(test.grant); got != test.want {
+			t.Errorf("%s principal: MayGrant(%q) = %v, want %v", test.role, test.grant, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/obieq/rva-devops-api/models"
+)
+
+// Rule => grants roles access to the requests matching methods and route
+type Rule struct {
+	// Methods => HTTP methods the rule applies to, e.g. ["GET", "HEAD"]; empty or "*" matches any
+	Methods []string `json:"methods"`
+	// Route => path pattern matched segment by segment: ":name" or "*" matches one segment and a
+	// trailing "**" matches the rest of the path, e.g. "/api/v1/automobiles/:id" or "/api/v1/**"
+	Route string `json:"route"`
+	// Roles => roles allowed through; empty denies everyone
+	Roles []string `json:"roles"`
+}
+
+// Policy => ordered rules; the first rule matching a request decides it and unmatched requests are denied
+type Policy []Rule
+
+// DefaultPolicy => readers may read, editors may also write, and only admins may manage api keys
+var DefaultPolicy = Policy{
+	{Route: "/api/v1/api-keys/**", Roles: []string{models.ADMIN_ROLE}},
+	{Methods: []string{"GET", "HEAD"}, Route: "/api/v1/**", Roles: []string{models.READER_ROLE, models.EDITOR_ROLE, models.ADMIN_ROLE}},
+	{Methods: []string{"POST", "PUT", "PATCH", "DELETE"}, Route: "/api/v1/**", Roles: []string{models.EDITOR_ROLE, models.ADMIN_ROLE}},
+}
+
+// LoadPolicy => reads a policy from a JSON file holding an array of rules
+func LoadPolicy(path string) (Policy, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var policy Policy
+	if err := json.NewDecoder(f).Decode(&policy); err != nil {
+		return nil, fmt.Errorf("auth: policy %s: %v", path, err)
+	}
+	return policy, policy.validate()
+}
+
+// Allowed => whether role may send a method request to path
+func (p Policy) Allowed(method, path, role string) bool {
+	for _, rule := range p {
+		if rule.matches(method, path) {
+			return contains(rule.Roles, role)
+		}
+	}
+	return false
+}
+
+func (p Policy) validate() error {
+	for i, rule := range p {
+		if !strings.HasPrefix(rule.Route, "/") {
+			return fmt.Errorf("auth: policy rule %d: route must start with /", i)
+		}
+		for _, role := range rule.Roles {
+			switch role {
+			case models.READER_ROLE, models.EDITOR_ROLE, models.ADMIN_ROLE:
+			default:
+				return fmt.Errorf("auth: policy rule %d: unknown role %q", i, role)
+			}
+		}
+	}
+	if len(p) == 0 {
+		return errors.New("auth: policy has no rules, so every request would be denied")
+	}
+	return nil
+}
+
+func (rule Rule) matches(method, path string) bool {
+	return MatchMethod(rule.Methods, method) && MatchRoute(rule.Route, path)
+}
+
+// MatchMethod => whether method is one of methods; empty methods or "*" match any
+func MatchMethod(methods []string, method string) bool {
+	return len(methods) == 0 || contains(methods, "*") || contains(methods, method)
+}
+
+// MatchRoute => whether path matches a route pattern (see Rule.Route)
+func MatchRoute(route, path string) bool {
+	patterns := strings.Split(strings.Trim(route, "/"), "/")
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for i, pattern := range patterns {
+		if pattern == "**" && i == len(patterns)-1 {
+			return true
+		}
+		if i >= len(segments) {
+			return false
+		}
+		if pattern != "*" && !strings.HasPrefix(pattern, ":") && pattern != segments[i] {
+			return false
+		}
+	}
+	return len(patterns) == len(segments)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,130 @@
+package auth
+
+import (
+	"testing"
+
+	"github.com/obieq/rva-devops-api/models"
+)
+
+func TestMatchRoute(t *testing.T) {
+	tests := []struct {
+		route, path string
+		want        bool
+	}{
+		{"/api/v1/automobiles", "/api/v1/automobiles", true},
+		{"/api/v1/automobiles", "/api/v1/automobiles/", true},
+		{"/api/v1/automobiles", "/api/v1/owners", false},
+		{"/api/v1/automobiles", "/api/v1/automobiles/1", false},
+		{"/api/v1/automobiles/:id", "/api/v1/automobiles/1", true},
+		{"/api/v1/automobiles/:id", "/api/v1/automobiles", false},
+		{"/api/v1/automobiles/:id", "/api/v1/automobiles/1/history", false},
+		{"/api/v1/*/:id", "/api/v1/owners/1", true},
+		{"/api/v1/**", "/api/v1", true},
+		{"/api/v1/**", "/api/v1/automobiles/1/relationships/owner", true},
+		{"/api/v1/**", "/api/v2/automobiles", false},
+		{"/api/v1/api-keys/**", "/api/v1/api-keys", true},
+		{"/api/v1/api-keys/**", "/api/v1/api-keys/1", true},
+		{"/**", "/", true},
+		{"/**", "/healthz", true},
+		{"/healthz", "/readyz", false},
+		// "**" only matches the rest of the path in last position
+		{"/api/**/history", "/api/v1/automobiles/1/history", false},
+	}
+
+	for _, test := range tests {
+		if got := MatchRoute(test.route, test.path); got != test.want {
+			t.Errorf("MatchRoute(%q, %q) = %v, want %v", test.route, test.path, got, test.want)
+		}
+	}
+}
+
+func TestMatchMethod(t *testing.T) {
+	tests := []struct {
+		methods []string
+		method  string
+		want    bool
+	}{
+		{nil, "DELETE", true},
+		{[]string{"*"}, "PATCH", true},
+		{[]string{"GET", "HEAD"}, "HEAD", true},
+		{[]string{"get"}, "GET", true},
+		{[]string{"GET", "HEAD"}, "POST", false},
+	}
+
+	for _, test := range tests {
+		if got := MatchMethod(test.methods, test.method); got != test.want {
+			t.Errorf("MatchMethod(%v, %q) = %v, want %v", test.methods, test.method, got, test.want)
+		}
+	}
+}
+
+func TestDefaultPolicyAllowed(t *testing.T) {
+	reader, editor, admin := models.READER_ROLE, models.EDITOR_ROLE, models.ADMIN_ROLE
+
+	tests := []struct {
+		method, path, role string
+		want               bool
+	}{
+		{"GET", "/api/v1/automobiles", reader, true},
+		{"HEAD", "/api/v1/automobiles/1", reader, true},
+		{"GET", "/api/v1/automobiles/1/history", reader, true},
+		{"POST", "/api/v1/automobiles", reader, false},
+		{"PATCH", "/api/v1/automobiles/1", reader, false},
+		{"DELETE", "/api/v1/automobiles/1", reader, false},
+		{"POST", "/api/v1/automobiles", editor, true},
+		{"DELETE", "/api/v1/owners/1", editor, true},
+		{"POST", "/api/v1/automobiles", admin, true},
+		{"GET", "/api/v1/api-keys", reader, false},
+		{"GET", "/api/v1/api-keys", editor, false},
+		{"POST", "/api/v1/api-keys", editor, false},
+		{"DELETE", "/api/v1/api-keys/1", admin, true},
+		// methods no rule grants are denied to everyone
+		{"OPTIONS", "/api/v1/automobiles", admin, false},
+		// as are paths outside of the rules
+		{"GET", "/api/v2/automobiles", admin, false},
+		{"GET", "/api/v1/automobiles", "", false},
+		{"GET", "/api/v1/automobiles", "owner", false},
+	}
+
+	for _, test := range tests {
+		if got := DefaultPolicy.Allowed(test.method, test.path, test.role); got != test.want {
+			t.Errorf("DefaultPolicy.Allowed(%q, %q, %q) = %v, want %v", test.method, test.path, test.role, got, test.want)
+		}
+	}
+}
+
+func TestPolicyAllowedFirstMatchDecides(t *testing.T) {
+	policy := Policy{
+		{Methods: []string{"DELETE"}, Route: "/api/v1/automobiles/:id", Roles: []string{models.ADMIN_ROLE}},
+		{Route: "/api/v1/**", Roles: []string{models.EDITOR_ROLE, models.ADMIN_ROLE}},
+	}
+
+	if policy.Allowed("DELETE", "/api/v1/automobiles/1", models.EDITOR_ROLE) {
+		t.Error("editors may delete automobiles, want the first rule to deny them")
+	}
+	if !policy.Allowed("DELETE", "/api/v1/owners/1", models.EDITOR_ROLE) {
+		t.Error("editors may not delete owners, want the second rule to allow them")
+	}
+	if !policy.Allowed("DELETE", "/api/v1/automobiles/1", models.ADMIN_ROLE) {
+		t.Error("admins may not delete automobiles")
+	}
+}
+
+func TestPolicyValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy Policy
+		valid  bool
+	}{
+		{"default", DefaultPolicy, true},
+		{"empty", Policy{}, false},
+		{"relative route", Policy{{Route: "api/v1/**", Roles: []string{models.READER_ROLE}}}, false},
+		{"unknown role", Policy{{Route: "/api/v1/**", Roles: []string{"owner"}}}, false},
+	}
+
+	for _, test := range tests {
+		if err := test.policy.validate(); (err == nil) != test.valid {
+			t.Errorf("%s policy: validate() = %v, want valid = %v", test.name, err, test.valid)
+		}
+	}
+}
@@ -0,0 +1,217 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+)
+
+// Config => server settings
+// Precedence (highest first): command-line flags, environment variables, .env file, defaults
+type Config struct {
+	// Addr => address the HTTP server listens on, e.g. ":5000"
+	Addr string
+	// BaseURL => public scheme + host used to build self links, e.g. "https://api.example.com"
+	// NOTE: when empty, links are derived from the request's Host / X-Forwarded-* headers
+	BaseURL string
+	// LogLevel => one of debug, info, warn, error
+	LogLevel string
+	// ReadTimeout, WriteTimeout, IdleTimeout => http.Server timeouts for reading a request, writing its
+	// response and keeping an idle keep-alive connection open
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+	IdleTimeout  time.Duration
+	// ShutdownDelay => how long to keep serving after SIGTERM/SIGINT, with /readyz failing, so load balancers
+	// stop routing here before the listener closes
+	ShutdownDelay time.Duration
+	// ShutdownTimeout => how long in-flight requests may take to finish once the listener closes
+	ShutdownTimeout time.Duration
+	// RedactHeaders => request headers masked in debug access logs, on top of Authorization, cookies and API keys
+	RedactHeaders []string
+	// AdminAPIKey => key that authenticates as the administrator, e.g. to issue the first api keys; "" disables it
+	AdminAPIKey string
+	// PolicyFile => JSON file with the authorization rules (see auth.Rule); "" uses auth.DefaultPolicy
+	PolicyFile string
+	// AuthDisabled => serve the API without authentication, e.g. for local development
+	AuthDisabled bool
+	// RateLimit => requests each client may send per period on routes without a limit of their own, e.g. "300/1m"; "0" disables it
+	RateLimit string
+	// RateLimitFile => JSON file with per-route limits (see middleware.RateLimit), applied before RateLimit
+	RateLimitFile string
+	// IPRateLimit => requests each client IP may send per period, checked before authentication so that
+	// requests with bad keys are limited too, e.g. "1200/1m"; "0" disables it
+	IPRateLimit string
+	// CORSOrigins => origins browsers may call the API from, or "*" for any; empty disables CORS
+	CORSOrigins []string
+	// CORSMethods => methods offered to preflights; empty offers every method routed for the path
+	CORSMethods []string
+	// CORSHeaders => request headers offered to preflights; empty uses middleware.DEFAULT_CORS_ALLOW_HEADERS
+	CORSHeaders []string
+	// CORSExposeHeaders => response headers scripts may read; empty uses middleware.DEFAULT_CORS_EXPOSE_HEADERS
+	CORSExposeHeaders []string
+	// CORSCredentials => let browsers send cookies and Authorization cross-origin
+	CORSCredentials bool
+	// CORSMaxAge => how long browsers may cache preflight responses
+	CORSMaxAge time.Duration
+	// Backend => name of the registered storage backend, e.g. "orchestrate" or "memory"
+	Backend string
+	// OrchestrateAPIKey => API key used by the orchestrate backend
+	OrchestrateAPIKey string
+}
+
+// Defaults
+const (
+	DEFAULT_ADDR       string = ":5000"
+	DEFAULT_LOG_LEVEL  string = "info"
+	DEFAULT_BACKEND    string = "orchestrate"
+	DEFAULT_RATE_LIMIT string = "300/1m"
+	// DEFAULT_IP_RATE_LIMIT => generous, since several clients may share an IP behind a NAT or proxy
+	DEFAULT_IP_RATE_LIMIT string = "1200/1m"
+)
+
+// Default durations
+const (
+	DEFAULT_READ_TIMEOUT     = 15 * time.Second
+	DEFAULT_WRITE_TIMEOUT    = 30 * time.Second
+	DEFAULT_IDLE_TIMEOUT     = 2 * time.Minute
+	DEFAULT_SHUTDOWN_TIMEOUT = 30 * time.Second
+	// DEFAULT_SHUTDOWN_DELAY => long enough for a few failed readiness probes before the listener closes
+	DEFAULT_SHUTDOWN_DELAY = 5 * time.Second
+	// DEFAULT_CORS_MAX_AGE => how long browsers cache preflight responses unless configured
+	DEFAULT_CORS_MAX_AGE = 10 * time.Minute
+)
+
+// Load => parses args (typically os.Args[1:]) on top of the environment and an optional .env file
+func Load(args []string) (*Config, error) {
+	// a .env file is optional; it never overrides variables that are already set
+	if err := godotenv.Load(); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	cfg := &Config{}
+	var port, redact, corsOrigins, corsMethods, corsHeaders, corsExpose string
+	var envErr error
+
+	fs := flag.NewFlagSet("rva-devops-api", flag.ContinueOnError)
+	fs.StringVar(&cfg.Addr, "addr", env("ADDR", DEFAULT_ADDR), "listen address (env ADDR)")
+	fs.StringVar(&port, "port", os.Getenv("PORT"), "listen port; overrides the port in -addr (env PORT)")
+	fs.StringVar(&cfg.BaseURL, "base-url", os.Getenv("BASE_URL"), "public base URL used in links (env BASE_URL)")
+	fs.StringVar(&cfg.LogLevel, "log-level", env("LOG_LEVEL", DEFAULT_LOG_LEVEL), "debug, info, warn or error (env LOG_LEVEL)")
+	fs.DurationVar(&cfg.ReadTimeout, "read-timeout", envDuration("READ_TIMEOUT", DEFAULT_READ_TIMEOUT, &envErr), "time allowed to read a request (env READ_TIMEOUT)")
+	fs.DurationVar(&cfg.WriteTimeout, "write-timeout", envDuration("WRITE_TIMEOUT", DEFAULT_WRITE_TIMEOUT, &envErr), "time allowed to write a response (env WRITE_TIMEOUT)")
+	fs.DurationVar(&cfg.IdleTimeout, "idle-timeout", envDuration("IDLE_TIMEOUT", DEFAULT_IDLE_TIMEOUT, &envErr), "how long idle keep-alive connections stay open (env IDLE_TIMEOUT)")
+	fs.DurationVar(&cfg.ShutdownDelay, "shutdown-delay", envDuration("SHUTDOWN_DELAY", DEFAULT_SHUTDOWN_DELAY, &envErr), "how long to keep serving, not ready, after a shutdown signal (env SHUTDOWN_DELAY)")
+	fs.DurationVar(&cfg.ShutdownTimeout, "shutdown-timeout", envDuration("SHUTDOWN_TIMEOUT", DEFAULT_SHUTDOWN_TIMEOUT, &envErr), "how long in-flight requests may take to drain on shutdown (env SHUTDOWN_TIMEOUT)")
+	fs.StringVar(&redact, "redact-headers", os.Getenv("REDACT_HEADERS"), "comma-separated request headers to mask in access logs (env REDACT_HEADERS)")
+	fs.StringVar(&cfg.AdminAPIKey, "admin-api-key", os.Getenv("ADMIN_API_KEY"), "administrator api key (env ADMIN_API_KEY)")
+	fs.StringVar(&cfg.PolicyFile, "policy-file", os.Getenv("POLICY_FILE"), "JSON authorization policy; defaults to the built-in one (env POLICY_FILE)")
+	fs.BoolVar(&cfg.AuthDisabled, "no-auth", os.Getenv("AUTH_DISABLED") == "true", "serve the API without authentication (env AUTH_DISABLED=true)")
+	fs.StringVar(&cfg.RateLimit, "rate-limit", env("RATE_LIMIT", DEFAULT_RATE_LIMIT), "requests per client and period, e.g. 300/1m; 0 disables (env RATE_LIMIT)")
+	fs.StringVar(&cfg.IPRateLimit, "ip-rate-limit", env("IP_RATE_LIMIT", DEFAULT_IP_RATE_LIMIT), "requests per client IP and period, before authentication; 0 disables (env IP_RATE_LIMIT)")
+	fs.StringVar(&cfg.RateLimitFile, "rate-limit-file", os.Getenv("RATE_LIMIT_FILE"), "JSON per-route rate limits (env RATE_LIMIT_FILE)")
+	fs.StringVar(&corsOrigins, "cors-origins", os.Getenv("CORS_ORIGINS"), "comma-separated origins allowed to call the API, or * (env CORS_ORIGINS)")
+	fs.StringVar(&corsMethods, "cors-methods", os.Getenv("CORS_METHODS"), "comma-separated methods offered to CORS preflights (env CORS_METHODS)")
+	fs.StringVar(&corsHeaders, "cors-headers", os.Getenv("CORS_HEADERS"), "comma-separated request headers offered to CORS preflights (env CORS_HEADERS)")
+	fs.StringVar(&corsExpose, "cors-expose-headers", os.Getenv("CORS_EXPOSE_HEADERS"), "comma-separated response headers exposed to scripts (env CORS_EXPOSE_HEADERS)")
+	fs.BoolVar(&cfg.CORSCredentials, "cors-credentials", os.Getenv("CORS_CREDENTIALS") == "true", "allow credentialed CORS requests (env CORS_CREDENTIALS=true)")
+	fs.DurationVar(&cfg.CORSMaxAge, "cors-max-age", envDuration("CORS_MAX_AGE", DEFAULT_CORS_MAX_AGE, &envErr), "how long preflight responses may be cached (env CORS_MAX_AGE)")
+	fs.StringVar(&cfg.Backend, "backend", env("STORAGE_BACKEND", DEFAULT_BACKEND), "storage backend (env STORAGE_BACKEND)")
+	fs.StringVar(&cfg.OrchestrateAPIKey, "orchestrate-api-key", orchestrateAPIKey(), "Orchestrate API key (env ORCHESTRATE_API_KEY)")
+
+	if envErr != nil {
+		return nil, envErr
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if port != "" {
+		host := cfg.Addr
+		if i := strings.LastIndex(host, ":"); i >= 0 {
+			host = host[:i]
+		}
+		cfg.Addr = host + ":" + port
+	}
+
+	cfg.RedactHeaders = list(redact)
+	cfg.CORSOrigins = list(corsOrigins)
+	cfg.CORSMethods = list(corsMethods)
+	cfg.CORSHeaders = list(corsHeaders)
+	cfg.CORSExposeHeaders = list(corsExpose)
+
+	return cfg, cfg.validate()
+}
+
+// BackendOptions => connection options handed to db.Open
+func (cfg *Config) BackendOptions() map[string]string {
+	return map[string]string{"api_key": cfg.OrchestrateAPIKey}
+}
+
+func (cfg *Config) validate() error {
+	switch cfg.LogLevel {
+	case "debug", "info", "warn", "error":
+	default:
+		return errors.New("config: log level must be one of debug, info, warn, error")
+	}
+
+	if cfg.BaseURL != "" {
+		u, err := url.Parse(cfg.BaseURL)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return errors.New("config: base url must be absolute, e.g. https://api.example.com")
+		}
+		cfg.BaseURL = strings.TrimRight(cfg.BaseURL, "/")
+	}
+
+	return nil
+}
+
+func env(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+// envDuration => the duration in key, e.g. "10m", or fallback if it is unset; a malformed value is reported
+// through err, unless it already holds an error
+func envDuration(key string, fallback time.Duration, err *error) time.Duration {
+	v := os.Getenv(key)
+	if v == "" {
+		return fallback
+	}
+
+	d, perr := time.ParseDuration(v)
+	if perr != nil {
+		if *err == nil {
+			*err = fmt.Errorf("config: %s must be a duration such as 30s, got %q", key, v)
+		}
+		return fallback
+	}
+	return d
+}
+
+// list => the non-empty items of a comma-separated list
+func list(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// orchestrateAPIKey => ORCHESTRATE_API_KEY may hold the key itself or the name of the env var that holds it
+func orchestrateAPIKey() string {
+	apiKey := os.Getenv("ORCHESTRATE_API_KEY")
+	if indirect := os.Getenv(apiKey); apiKey != "" && indirect != "" {
+		apiKey = indirect
+	}
+	return apiKey
+}
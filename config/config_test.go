@@ -0,0 +1,164 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// envKeys => variables the tests set, through the environment or a .env file
+var envKeys = []string{"ADDR", "PORT", "BASE_URL", "LOG_LEVEL", "SHUTDOWN_DELAY", "SHUTDOWN_TIMEOUT"}
+
+// load => runs Load with args in a directory holding dotenv as its .env file (none if empty) and with env set,
+// restoring the working directory and environment afterwards
+func load(t *testing.T, dotenv string, env map[string]string, args ...string) (*Config, error) {
+	saved := make(map[string]string)
+	for _, key := range envKeys {
+		if v, ok := os.LookupEnv(key); ok {
+			saved[key] = v
+		}
+		os.Unsetenv(key)
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		os.Chdir(wd)
+		os.RemoveAll(dir)
+		for _, key := range envKeys {
+			if v, ok := saved[key]; ok {
+				os.Setenv(key, v)
+			} else {
+				os.Unsetenv(key)
+			}
+		}
+	}()
+
+	if dotenv != "" {
+		if err := ioutil.WriteFile(filepath.Join(dir, ".env"), []byte(dotenv), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	for key, v := range env {
+		os.Setenv(key, v)
+	}
+
+	return Load(args)
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	dotenv := "LOG_LEVEL=warn\n"
+
+	tests := []struct {
+		name   string
+		dotenv string
+		env    map[string]string
+		args   []string
+		want   string
+	}{
+		{"default", "", nil, nil, DEFAULT_LOG_LEVEL},
+		{".env over default", dotenv, nil, nil, "warn"},
+		{"env over .env", dotenv, map[string]string{"LOG_LEVEL": "error"}, nil, "error"},
+		{"flag over env", dotenv, map[string]string{"LOG_LEVEL": "error"}, []string{"-log-level=debug"}, "debug"},
+	}
+
+	for _, test := range tests {
+		cfg, err := load(t, test.dotenv, test.env, test.args...)
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if cfg.LogLevel != test.want {
+			t.Errorf("%s: log level %q, want %q", test.name, cfg.LogLevel, test.want)
+		}
+	}
+}
+
+func TestLoadAddr(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		args []string
+		want string
+	}{
+		{"default", nil, nil, DEFAULT_ADDR},
+		{"env", map[string]string{"ADDR": "127.0.0.1:8080"}, nil, "127.0.0.1:8080"},
+		{"port flag, as in the Procfile", nil, []string{"-port=3000"}, ":3000"},
+		{"port keeps the host", nil, []string{"-addr=127.0.0.1:8080", "-port=3000"}, "127.0.0.1:3000"},
+		{"PORT keeps the host of ADDR", map[string]string{"ADDR": "0.0.0.0:8080", "PORT": "3000"}, nil, "0.0.0.0:3000"},
+	}
+
+	for _, test := range tests {
+		cfg, err := load(t, "", test.env, test.args...)
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if cfg.Addr != test.want {
+			t.Errorf("%s: addr %q, want %q", test.name, cfg.Addr, test.want)
+		}
+	}
+}
+
+func TestLoadBaseURL(t *testing.T) {
+	cfg, err := load(t, "", map[string]string{"BASE_URL": "https://api.example.com/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.BaseURL != "https://api.example.com" {
+		t.Errorf("base url %q, want the trailing slash trimmed", cfg.BaseURL)
+	}
+
+	for _, baseURL := range []string{"/api", "api.example.com", "//api.example.com"} {
+		if _, err := load(t, "", map[string]string{"BASE_URL": baseURL}); err == nil {
+			t.Errorf("relative base url %q was accepted", baseURL)
+		}
+	}
+}
+
+func TestLoadShutdown(t *testing.T) {
+	cfg, err := load(t, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.ShutdownDelay != DEFAULT_SHUTDOWN_DELAY || cfg.ShutdownTimeout != DEFAULT_SHUTDOWN_TIMEOUT {
+		t.Errorf("defaults: delay %v, timeout %v", cfg.ShutdownDelay, cfg.ShutdownTimeout)
+	}
+
+	cfg, err = load(t, "SHUTDOWN_DELAY=0s\n", map[string]string{"SHUTDOWN_TIMEOUT": "1m"}, "-shutdown-delay=2s")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.ShutdownDelay != 2*time.Second || cfg.ShutdownTimeout != time.Minute {
+		t.Errorf("configured: delay %v, timeout %v, want 2s and 1m", cfg.ShutdownDelay, cfg.ShutdownTimeout)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		args []string
+	}{
+		{"unknown log level", nil, []string{"-log-level=loud"}},
+		{"unknown flag", nil, []string{"-verbose"}},
+		{"malformed duration in the environment", map[string]string{"SHUTDOWN_TIMEOUT": "30"}, nil},
+		{"malformed duration flag", nil, []string{"-shutdown-delay=soon"}},
+	}
+
+	for _, test := range tests {
+		if _, err := load(t, "", test.env, test.args...); err == nil {
+			t.Errorf("%s: Load succeeded, want an error", test.name)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/go-martini/martini"
+	"github.com/martini-contrib/render"
+	"github.com/obieq/rva-devops-api/auth"
+	"github.com/obieq/rva-devops-api/resources"
+)
+
+// IssueAPIKey => POST /api-keys; the response is the only time the key's token is shown
+// NOTE: who may manage keys is up to the authorization policy (see auth.DefaultPolicy); callers may not issue
+// keys with a role above their own
+func (c *ResourceController) IssueAPIKey(request resources.JsonApiRequest, principal *auth.Principal, root resources.APIRoot, r render.Render) {
+	resource := &resources.APIKey{}
+	if err := json.Unmarshal(request.Data, resource); err != nil {
+		HandleError(400, err, r)
+		return
+	}
+	if t := resource.ResourceType; t != "" && t != c.Type {
+		HandleError(409, fmt.Errorf("type %q does not match the endpoint's type %q", t, c.Type), r)
+		return
+	}
+	if !principal.MayGrant(resource.Role) {
+		HandleError(403, fmt.Errorf("role %q outranks the caller's role %q", resource.Role, principal.Role), r)
+		return
+	}
+
+	key, token, err := auth.Issue(resource.Name, resource.Role)
+
+	var issued resources.JsonApiResourcer = resource
+	if key != nil {
+		issued = c.ToResource(key, root)
+		issued.(*resources.APIKey).Token = token
+	}
+
+	HandlePostResponse(token != "", err, issued, r)
+}
+
+// RevokeAPIKey => DELETE /api-keys/:id; the key is kept, marked as revoked, for auditing
+func (c *ResourceController) RevokeAPIKey(args martini.Params, r render.Render) {
+	if _, err := auth.Revoke(args["id"]); err != nil {
+		HandleBackendError(err, r)
+		return
+	}
+
+	r.Status(204)
+}
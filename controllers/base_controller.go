@@ -1,9 +1,12 @@
 package controllers
 
 import (
+	"fmt"
 	"reflect"
+	"strconv"
 	"strings"
 
+	"github.com/obieq/rva-devops-api/db"
 	"github.com/obieq/rva-devops-api/resources"
 
 	"github.com/gedex/inflector"
@@ -25,11 +28,52 @@ func ConvertModelNametoJsonApiName(t reflect.Type, isSingular bool) string {
 	}
 }
 
-func HandleIndexResponse(resultError error, link resources.Link, result interface{}, r render.Render) {
+// CollectionModel => goar model whose collection can be listed a page at a time (see db.ArBackend)
+type CollectionModel interface {
+	goar.ActiveRecordInterfacer
+	AllPage(models interface{}, opts map[string]interface{}) (db.Page, error)
+}
+
+// FindCollection => lists a page of models by key or, when the request filters or sorts them, searches the collection
+func FindCollection(ar CollectionModel, q resources.CollectionQuery, collectionURL string, models interface{}) (resources.PaginationLinks, error) {
+	page := q.Page
+
+	if !q.Searching() {
+		if page.Offset > 0 {
+			return resources.PaginationLinks{}, &resources.ParameterError{Parameter: "page[offset]", Message: "is only supported together with filter or sort; use page[after]"}
+		}
+
+		cursors, err := ar.AllPage(models, page.Options())
+		return page.Links(collectionURL, q.Values, cursors), err
+	}
+
+	if page.After != "" {
+		return resources.PaginationLinks{}, &resources.ParameterError{Parameter: "page[after]", Message: "cannot be combined with filter or sort; use page[offset]"}
+	}
+
+	for _, condition := range q.Filters {
+		ar.Where(condition)
+	}
+	for _, orderBy := range q.Sorts {
+		ar.Order(orderBy)
+	}
+	if q.Plucks != nil {
+		ar.Pluck(q.Plucks...)
+	}
+	ar.Query().Limit = strconv.Itoa(page.Size)
+	ar.Query().Offset = strconv.Itoa(page.Offset)
+
+	err := ar.Run(models)
+	return page.OffsetLinks(collectionURL, q.Values, reflect.ValueOf(models).Elem().Len()), err
+}
+
+func HandleIndexResponse(resultError error, links resources.PaginationLinks, meta map[string]interface{}, result interface{}, included []interface{}, r render.Render) {
 	if resultError == nil {
-		r.JSON(200, map[string]interface{}{"links": link, "data": result}) // TODO: return links before data
+		r.JSON(200, resources.CollectionDocument{Links: links, Meta: meta, Data: result, Included: included})
+	} else if _, ok := resultError.(*resources.ParameterError); ok {
+		HandleRequestError(resultError, r)
 	} else {
-		r.JSON(404, map[string]interface{}{"errors": resultError})
+		HandleBackendError(resultError, r)
 	}
 }
 
@@ -38,7 +82,16 @@ func HandleIndexResponseOrig(resultError error, result interface{}, r render.Ren
 		jsonApiName := ConvertModelNametoJsonApiName(reflect.TypeOf(result), false)
 		r.JSON(200, map[string]interface{}{jsonApiName: result})
 	} else {
-		r.JSON(400, map[string]interface{}{"errors": resultError})
+		HandleBackendError(resultError, r)
+	}
+}
+
+// HandleShowResponse => same as HandleGetResponse, plus the resources requested via include
+func HandleShowResponse(resultError error, result interface{}, included []interface{}, r render.Render) {
+	if resultError == nil {
+		r.JSON(200, resources.Document{Data: result, Included: included})
+	} else {
+		HandleGetResponse(resultError, nil, r)
 	}
 }
 
@@ -46,7 +99,7 @@ func HandleGetResponse(resultError error, result interface{}, r render.Render) {
 	if resultError == nil {
 		r.JSON(200, map[string]interface{}{"data": result})
 	} else {
-		r.JSON(404, map[string]interface{}{"errors": resultError})
+		HandleBackendError(resultError, r)
 	}
 }
 
@@ -57,11 +110,9 @@ func HandleSaveResponse(success bool, resultError error, model goar.ActiveRecord
 		// TODO: retrieve from the database instead of re-using instance
 		r.JSON(201, map[string]interface{}{jsonApiName: model})
 	} else if resultError != nil {
-		// TODO: how do I parse the status code?
-		r.JSON(400, map[string]interface{}{"errors": resultError})
-		//r.JSON(412, map[string]interface{}{"errors": err})
+		HandleBackendError(resultError, r)
 	} else {
-		r.JSON(422, map[string]interface{}{"errors": model.Errors()})
+		RenderErrors(422, resources.ValidationErrors(model.Errors()), r)
 	}
 }
 
@@ -72,34 +123,50 @@ func HandlePostResponse(success bool, resultError error, resource resources.Json
 		r.Header().Set("Location", resource.SelfLink())
 		r.JSON(201, map[string]interface{}{"data": resource})
 	} else if resultError != nil {
-		// TODO: how do I parse the status code?
-		r.JSON(400, map[string]interface{}{"errors": resultError})
-		//r.JSON(412, map[string]interface{}{"errors": err})
+		HandleBackendError(resultError, r)
 	} else {
-		r.JSON(422, map[string]interface{}{"errors": resource.Errors()})
+		RenderErrors(422, resource.Errors(), r)
 	}
 }
 
-// HandlePatchResponse => formats appropriate JSON response based on success vs. error
-// NOTE: used by both the PUT and PATCH methods
+// HandlePutPatchResponse => formats appropriate JSON response based on success vs. error
+// NOTE: responds 200 with the resource b/c the server always changes it (updated-at)
 func HandlePutPatchResponse(success bool, resultError error, resource resources.JsonApiResourcer, r render.Render) {
 	if success {
 		// TODO: retrieve from the database instead of re-using instance
-		r.JSON(204, map[string]interface{}{})
+		r.JSON(200, map[string]interface{}{"data": resource})
 	} else if resultError != nil {
-		// TODO: how do I parse the status code?
-		r.JSON(400, map[string]interface{}{"errors": resultError})
-		//r.JSON(412, map[string]interface{}{"errors": err})
+		HandleBackendError(resultError, r)
 	} else {
-		r.JSON(422, map[string]interface{}{"errors": resource.Errors()})
+		RenderErrors(422, resource.Errors(), r)
 	}
 }
 
+// HandlePatchRequestErrors => renders 409 for a mismatched type/id and 400 for bad attributes;
+// returns true if a response was written
+func HandlePatchRequestErrors(request resources.JsonApiPatchRequest, resourceType, id string, err error, r render.Render) bool {
+	if t := request.Member("type"); t != "" && t != resourceType {
+		HandleError(409, fmt.Errorf("type %q does not match the endpoint's type %q", t, resourceType), r)
+		return true
+	}
+	if i := request.Member("id"); i != "" && i != id {
+		HandleError(409, fmt.Errorf("id %q does not match the url's id %q", i, id), r)
+		return true
+	}
+
+	if err != nil {
+		HandleRequestError(err, r)
+		return true
+	}
+
+	return false
+}
+
 func HandleDeleteResponse(model goar.ActiveRecordInterfacer, r render.Render) {
 	goar.ToAR(model)
 
 	if err := model.Delete(); err != nil {
-		r.JSON(400, map[string]interface{}{"errors": err})
+		HandleBackendError(err, r)
 	} else {
 		r.JSON(204, map[string]interface{}{})
 	}
@@ -0,0 +1,110 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/martini-contrib/binding"
+	"github.com/martini-contrib/render"
+	as "github.com/obieq/goar/active_support"
+	"github.com/obieq/rva-devops-api/db"
+	"github.com/obieq/rva-devops-api/logger"
+	"github.com/obieq/rva-devops-api/resources"
+)
+
+// RenderErrors => writes a JSON API error document
+func RenderErrors(status int, errs []resources.Error, r render.Render) {
+	r.JSON(status, map[string]interface{}{"errors": errs})
+}
+
+// HandleError => renders a backend (or any other) error as a single JSON API error object
+func HandleError(status int, err error, r render.Render) {
+	RenderErrors(status, []resources.Error{resources.NewError(status, err.Error())}, r)
+}
+
+// StatusFor => HTTP status for a backend error, by its db.ErrorKind
+// NOTE: the backend rejecting our credentials is the server's problem, not the client's, hence 500
+func StatusFor(err error) int {
+	switch db.KindOf(err) {
+	case db.NotFound:
+		return 404
+	case db.Conflict:
+		return 409
+	case db.PreconditionFailed:
+		return 412
+	case db.Unavailable:
+		return 503
+	}
+	return 500
+}
+
+// HandleBackendError => renders a backend error with the status of its kind
+// NOTE: details of 5xx errors are logged rather than returned, so backend internals do not leak to clients
+func HandleBackendError(err error, r render.Render) {
+	status := StatusFor(err)
+	if status < 500 {
+		HandleError(status, err, r)
+		return
+	}
+
+	logger.Error("backend:", db.KindOf(err), "=>", err)
+	RenderErrors(status, []resources.Error{resources.NewError(status, "the storage backend failed to handle the request")}, r)
+}
+
+// HandleRelationError => renders a failure to read or write graph relations
+func HandleRelationError(err error, r render.Render) {
+	if err == db.ErrRelationsUnsupported {
+		HandleError(501, err, r)
+	} else {
+		HandleBackendError(err, r)
+	}
+}
+
+// HandleEventError => renders a failure to read or write events
+func HandleEventError(err error, r render.Render) {
+	if err == db.ErrEventsUnsupported {
+		HandleError(501, err, r)
+	} else {
+		HandleBackendError(err, r)
+	}
+}
+
+// HandleRequestError => renders a malformed attribute or query parameter as a 400, pointing at its source
+func HandleRequestError(err error, r render.Render) {
+	e := resources.NewError(400, err.Error())
+
+	switch t := err.(type) {
+	case *resources.AttributeError:
+		e.Source = &resources.ErrorSource{Pointer: resources.AttributePointer(t.Attribute)}
+	case *resources.ParameterError:
+		e.Source = &resources.ErrorSource{Parameter: t.Parameter}
+	}
+
+	RenderErrors(400, []resources.Error{e}, r)
+}
+
+// HandleBindingErrors => renders deserialization and binding validation errors
+// NOTE: register directly after binding.Json; writing the response halts the remaining handlers
+func HandleBindingErrors(errs binding.Errors, r render.Render) {
+	if len(errs) == 0 {
+		return
+	}
+
+	status := binding.StatusUnprocessableEntity
+	if errs.Has(binding.DeserializationError) {
+		status = http.StatusBadRequest
+	} else if errs.Has(binding.ContentTypeError) {
+		status = http.StatusUnsupportedMediaType
+	}
+
+	result := make([]resources.Error, len(errs))
+	for i, e := range errs {
+		result[i] = resources.NewError(status, e.Message)
+		result[i].Code = as.String(e.Kind()).Dasherize()
+
+		if fields := e.Fields(); len(fields) > 0 {
+			result[i].Source = &resources.ErrorSource{Pointer: resources.AttributePointer(as.String(fields[0]).Dasherize())}
+		}
+	}
+
+	RenderErrors(status, result, r)
+}
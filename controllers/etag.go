@@ -0,0 +1,124 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/martini-contrib/render"
+	"github.com/obieq/rva-devops-api/db"
+	"github.com/obieq/rva-devops-api/resources"
+)
+
+// errModified => If-Match named none of the stored value's current entity tags
+var errModified = errors.New("the resource has been modified; fetch it again and retry with its current ETag")
+
+// ETag => strong entity tag of a stored value's ref, e.g. "a1b2c3"
+func ETag(ref string) string {
+	return `"` + ref + `"`
+}
+
+// ModelETag => the model's ETag, or "" if its ref is unknown
+func ModelETag(model interface{}) string {
+	if v, ok := model.(db.Versioned); ok && v.Ref() != "" {
+		return ETag(v.Ref())
+	}
+	return ""
+}
+
+// SetETag => sends the model's ETag, if its ref is known
+func SetETag(model interface{}, r render.Render) {
+	if etag := ModelETag(model); etag != "" {
+		r.Header().Set("ETag", etag)
+	}
+}
+
+// CheckIfMatch => whether the model (as loaded by Find) satisfies the request's If-Match; renders a 412 if not
+// NOTE: a matching model keeps its ref, so the following save or delete only succeeds if nobody wrote in between
+func CheckIfMatch(req *http.Request, model interface{}, r render.Render) bool {
+	ifMatch := req.Header.Get("If-Match")
+	if ifMatch == "" || strings.TrimSpace(ifMatch) == "*" {
+		return true
+	}
+
+	if v, ok := model.(db.Versioned); ok && v.Ref() != "" {
+		current := ETag(v.Ref())
+		for _, tag := range strings.Split(ifMatch, ",") {
+			// If-Match uses the strong comparison, so weak tags (W/"...") never match
+			if strings.TrimSpace(tag) == current {
+				return true
+			}
+		}
+	}
+
+	HandleError(412, errModified, r)
+	return false
+}
+
+// WeakETag => weak entity tag over a document's JSON, for responses that no single ref describes (e.g. a page)
+func WeakETag(document interface{}) string {
+	raw, err := json.Marshal(document)
+	if err != nil {
+		return ""
+	}
+
+	h := fnv.New64a()
+	h.Write(raw)
+	return fmt.Sprintf(`W/"%x"`, h.Sum64())
+}
+
+// LastModified => when the resource last changed: updated-at, or created-at if it was never updated
+func LastModified(resource resources.JsonApiResourcer) *time.Time {
+	base := resource.Base()
+	if base.UpdatedAt != nil {
+		return base.UpdatedAt
+	}
+	return base.CreatedAt
+}
+
+// NotModified => sends the validators (either may be empty/nil) and, when the request's If-None-Match or
+// If-Modified-Since shows the client's copy is current, responds 304 and returns true
+// NOTE: If-Modified-Since is ignored when If-None-Match is present, per RFC 7232
+func NotModified(req *http.Request, etag string, lastModified *time.Time, r render.Render) bool {
+	if etag != "" {
+		r.Header().Set("ETag", etag)
+	}
+	if lastModified != nil {
+		r.Header().Set("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+
+	if req.Method != "GET" && req.Method != "HEAD" {
+		return false
+	}
+
+	notModified := false
+	if ifNoneMatch := req.Header.Get("If-None-Match"); ifNoneMatch != "" {
+		notModified = etag != "" && matchesWeakly(ifNoneMatch, etag)
+	} else if since, err := http.ParseTime(req.Header.Get("If-Modified-Since")); err == nil && lastModified != nil {
+		// HTTP dates have one second resolution
+		notModified = !lastModified.Truncate(time.Second).After(since)
+	}
+
+	if notModified {
+		r.Status(304)
+	}
+	return notModified
+}
+
+// matchesWeakly => whether an If-None-Match list names etag, using the weak comparison
+func matchesWeakly(list, etag string) bool {
+	if strings.TrimSpace(list) == "*" {
+		return true
+	}
+
+	for _, tag := range strings.Split(list, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(tag), "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
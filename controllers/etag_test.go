@@ -0,0 +1,126 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-martini/martini"
+	"github.com/martini-contrib/render"
+)
+
+// versioned => a db.Versioned model
+type versioned struct {
+	ref string
+}
+
+func (v *versioned) Ref() string       { return v.ref }
+func (v *versioned) SetRef(ref string) { v.ref = ref }
+
+// serve => runs handler for req behind render.Renderer, as the server does
+func serve(handler martini.Handler, req *http.Request) *httptest.ResponseRecorder {
+	m := martini.New()
+	m.Use(render.Renderer())
+	m.Map(req)
+	m.Action(handler)
+
+	res := httptest.NewRecorder()
+	m.ServeHTTP(res, req)
+	return res
+}
+
+func TestCheckIfMatch(t *testing.T) {
+	tests := []struct {
+		ifMatch string
+		model   interface{}
+		want    bool
+	}{
+		{"", &versioned{"r1"}, true},
+		{"*", &versioned{"r1"}, true},
+		{`"r1"`, &versioned{"r1"}, true},
+		{`"r0", "r1"`, &versioned{"r1"}, true},
+		{`"r0"`, &versioned{"r1"}, false},
+		// If-Match uses the strong comparison
+		{`W/"r1"`, &versioned{"r1"}, false},
+		// without a ref there is nothing to match
+		{`"r1"`, &versioned{}, false},
+		{`"r1"`, struct{}{}, false},
+	}
+
+	for _, test := range tests {
+		req, _ := http.NewRequest("PUT", "/", nil)
+		if test.ifMatch != "" {
+			req.Header.Set("If-Match", test.ifMatch)
+		}
+
+		var got bool
+		res := serve(func(req *http.Request, r render.Render) {
+			if got = CheckIfMatch(req, test.model, r); got {
+				r.Status(204)
+			}
+		}, req)
+
+		if got != test.want {
+			t.Errorf("If-Match %s on %+v: CheckIfMatch = %v, want %v", test.ifMatch, test.model, got, test.want)
+		}
+		if !got && res.Code != 412 {
+			t.Errorf("If-Match %s on %+v: status %d, want 412", test.ifMatch, test.model, res.Code)
+		}
+	}
+}
+
+func TestNotModified(t *testing.T) {
+	modified := time.Date(2015, 6, 1, 12, 0, 0, 500, time.UTC)
+	etag := ETag("r1")
+
+	tests := []struct {
+		name, method  string
+		etag          string
+		lastModified  *time.Time
+		header, value string
+		want          bool
+	}{
+		{"no validators sent", "GET", etag, &modified, "", "", false},
+		{"matching etag", "GET", etag, &modified, "If-None-Match", `"r1"`, true},
+		{"matching etag on HEAD", "HEAD", etag, &modified, "If-None-Match", `"r1"`, true},
+		{"one of several etags", "GET", etag, nil, "If-None-Match", `"r0", "r1"`, true},
+		{"weak comparison", "GET", etag, nil, "If-None-Match", `W/"r1"`, true},
+		{"weak etag", "GET", `W/"abc"`, nil, "If-None-Match", `"abc"`, true},
+		{"any etag", "GET", etag, nil, "If-None-Match", "*", true},
+		{"stale etag", "GET", etag, &modified, "If-None-Match", `"r0"`, false},
+		{"no etag to match", "GET", "", &modified, "If-None-Match", `"r1"`, false},
+		{"writes are never 304", "PUT", etag, nil, "If-None-Match", `"r1"`, false},
+		{"unchanged since", "GET", "", &modified, "If-Modified-Since", "Mon, 01 Jun 2015 12:00:00 GMT", true},
+		{"changed since", "GET", "", &modified, "If-Modified-Since", "Mon, 01 Jun 2015 11:59:59 GMT", false},
+		{"malformed date", "GET", "", &modified, "If-Modified-Since", "yesterday", false},
+		{"no last modified", "GET", etag, nil, "If-Modified-Since", "Mon, 01 Jun 2015 12:00:00 GMT", false},
+	}
+
+	for _, test := range tests {
+		req, _ := http.NewRequest(test.method, "/", nil)
+		if test.header != "" {
+			req.Header.Set(test.header, test.value)
+		}
+
+		var got bool
+		res := serve(func(req *http.Request, r render.Render) {
+			if got = NotModified(req, test.etag, test.lastModified, r); !got {
+				r.Status(200)
+			}
+		}, req)
+
+		if got != test.want {
+			t.Errorf("%s: NotModified = %v, want %v", test.name, got, test.want)
+		}
+		if want := map[bool]int{true: 304, false: 200}[test.want]; res.Code != want {
+			t.Errorf("%s: status %d, want %d", test.name, res.Code, want)
+		}
+		if res.Header().Get("ETag") != test.etag {
+			t.Errorf("%s: ETag %q, want %q", test.name, res.Header().Get("ETag"), test.etag)
+		}
+		if test.lastModified != nil && res.Header().Get("Last-Modified") != "Mon, 01 Jun 2015 12:00:00 GMT" {
+			t.Errorf("%s: Last-Modified %q", test.name, res.Header().Get("Last-Modified"))
+		}
+	}
+}
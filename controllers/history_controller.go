@@ -0,0 +1,125 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/go-martini/martini"
+	"github.com/martini-contrib/render"
+	"github.com/obieq/rva-devops-api/auth"
+	"github.com/obieq/rva-devops-api/db"
+	"github.com/obieq/rva-devops-api/resources"
+)
+
+// History => GET /:type/:id/history; the resource's changes, newest first, filtered by filter[since] and filter[until]
+// and paged with page[size] and page[before]
+// NOTE: history outlives the resource, so deleted resources still have one
+func (c *ResourceController) History(args martini.Params, req *http.Request, root resources.APIRoot, r render.Render) {
+	query := req.URL.Query()
+	historyRange, err := resources.ParseHistoryRange(query, time.Now().UTC())
+	if err != nil {
+		HandleRequestError(err, r)
+		return
+	}
+
+	eventer, err := db.Events()
+	if err != nil {
+		HandleEventError(err, r)
+		return
+	}
+	// NOTE: a batch can be full of changes the cursor skips (see HistoryRange.Includes), so batches are fetched
+	// until the page is full or the backend has no more
+	changes := make([]*resources.Change, 0, historyRange.Size)
+	var last resources.ChangeCursor
+	more := false
+	for fetch := historyRange; ; {
+		events, err := eventer.GetEventsInRange(c.collection, args["id"], db.HISTORY_EVENT, fetch.Since, fetch.Until, fetch.Limit())
+		if err != nil {
+			HandleEventError(err, r)
+			return
+		}
+
+		for _, event := range events {
+			cursor := resources.NewChangeCursor(event.Timestamp, event.Ordinal)
+			if !fetch.Includes(cursor) {
+				continue
+			}
+			if len(changes) == historyRange.Size {
+				more = true
+				break
+			}
+
+			var change db.Change
+			if err := event.Value(&change); err != nil {
+				HandleError(500, err, r)
+				return
+			}
+
+			recorded := event.Timestamp
+			changes = append(changes, &resources.Change{
+				BaseResource: resources.BaseResource{
+					ResourceType: resources.CHANGE_RESOURCE_TYPE,
+					ID:           cursor.String(),
+					CreatedAt:    &recorded,
+				},
+				Action:    change.Action,
+				Principal: change.Principal,
+				KeyID:     change.KeyID,
+				Before:    c.attributes(change.Before),
+				After:     c.attributes(change.After),
+			})
+			last = cursor
+		}
+
+		// a full batch may have been followed by more changes
+		full := len(events) == fetch.Limit()
+		if len(changes) == historyRange.Size {
+			more = more || full
+			break
+		}
+		if !full {
+			break
+		}
+
+		// the page is short: fetch the changes before the batch's oldest, unless the whole batch was recorded in the
+		// cursor's millisecond, which paging by time cannot get past
+		oldest := events[len(events)-1]
+		cursor := resources.NewChangeCursor(oldest.Timestamp, oldest.Ordinal)
+		if !fetch.Includes(cursor) {
+			break
+		}
+		fetch = fetch.Before(cursor)
+	}
+
+	links := historyRange.Links(root.Join(c.Type, args["id"], "history"), query, more, last)
+	meta := map[string]interface{}{"page": map[string]interface{}{"size": historyRange.Size}}
+	HandleIndexResponse(nil, links, meta, changes, nil, r)
+}
+
+// attributes => a stored model's JSON as the resource's attributes; nil if there is none
+func (c *ResourceController) attributes(stored json.RawMessage) *json.RawMessage {
+	if len(stored) == 0 {
+		return nil
+	}
+
+	model := c.NewModel()
+	if err := json.Unmarshal(stored, model); err != nil {
+		return nil
+	}
+	resource := c.NewResource()
+	resources.MapFromModel(c.Type, resource, model)
+
+	raw, err := json.Marshal(resource)
+	if err != nil {
+		return nil
+	}
+	return (*json.RawMessage)(&raw)
+}
+
+// attribute => names the principal making a change to model in its history
+func attribute(model interface{}, principal *auth.Principal) {
+	if a, ok := model.(db.Attributable); ok && principal != nil {
+		a.SetPrincipal(principal.Name, principal.KeyID)
+	}
+}
@@ -0,0 +1,168 @@
+package controllers
+
+import (
+	"net/url"
+	"sync"
+
+	"github.com/obieq/rva-devops-api/db"
+	"github.com/obieq/rva-devops-api/resources"
+)
+
+// INCLUDE_WORKERS => maximum number of related lookups a single request runs at once
+const INCLUDE_WORKERS = 8
+
+// includeTask => one relationship of one resource to load while building the included resources
+type includeTask struct {
+	c        *ResourceController
+	resource resources.JsonApiResourcer
+	rel      *Relationship
+	include  resources.Include // paths to include beyond rel
+	related  []db.Related
+	err      error
+}
+
+// ParseIncludeQuery => parses include against the controller's relationships, plus fields[type] for each included type
+func (c *ResourceController) ParseIncludeQuery(query url.Values) (resources.Include, map[string][]string, error) {
+	include := resources.ParseInclude(query)
+	fieldsets := make(map[string][]string)
+
+	if err := c.checkInclude(query, include, "", fieldsets); err != nil {
+		return nil, nil, err
+	}
+
+	return include, fieldsets, nil
+}
+
+func (c *ResourceController) checkInclude(query url.Values, include resources.Include, prefix string, fieldsets map[string][]string) error {
+	for name, sub := range include {
+		rel, ok := c.relationships[name]
+		if !ok {
+			return &resources.ParameterError{Parameter: "include", Message: "unknown relationship path " + prefix + name}
+		}
+
+		if _, parsed := fieldsets[rel.Related.Type]; !parsed {
+			fields, err := resources.ParseFieldset(query, rel.Related.Type, rel.Related.keys)
+			if err != nil {
+				return err
+			}
+			fieldsets[rel.Related.Type] = fields
+		}
+
+		if err := rel.Related.checkInclude(query, sub, prefix+name+".", fieldsets); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Include => loads the included relationships of the primary resources, setting their linkage, and returns
+// the related resources once each; resources that are also primary data are not repeated
+// NOTE: each level of the include tree is fetched concurrently by at most INCLUDE_WORKERS goroutines
+func (c *ResourceController) Include(primary []resources.JsonApiResourcer, include resources.Include, root resources.APIRoot) ([]resources.JsonApiResourcer, error) {
+	var included []resources.JsonApiResourcer
+	var tasks []*includeTask
+
+	seen := make(map[string]resources.JsonApiResourcer)
+	queued := make(map[string]bool)
+
+	enqueue := func(c *ResourceController, resource resources.JsonApiResourcer, include resources.Include) {
+		for _, name := range include.Names() {
+			key := resourceKey(resource) + "/" + name
+			if !queued[key] {
+				queued[key] = true
+				tasks = append(tasks, &includeTask{c: c, resource: resource, rel: c.relationships[name], include: include[name]})
+			}
+		}
+	}
+
+	for _, resource := range primary {
+		seen[resourceKey(resource)] = resource
+		enqueue(c, resource, include)
+	}
+
+	for len(tasks) > 0 {
+		level := tasks
+		tasks = nil
+
+		parallel(len(level), INCLUDE_WORKERS, func(i int) {
+			t := level[i]
+			t.related, t.err = t.c.related(t.rel, t.resource.Base().ID)
+		})
+
+		for _, t := range level {
+			if t.err != nil {
+				return nil, t.err
+			}
+
+			linkages := make([]resources.Linkage, len(t.related))
+			for i, item := range t.related {
+				linkages[i] = resources.Linkage{Type: t.rel.Related.Type, ID: item.Key}
+
+				resource, ok := seen[t.rel.Related.Type+"/"+item.Key]
+				if !ok {
+					model := t.rel.Related.NewModel()
+					if err := item.Value(model); err != nil {
+						return nil, err
+					}
+					resource = t.rel.Related.ToResource(model, root)
+					seen[resourceKey(resource)] = resource
+					included = append(included, resource)
+				}
+
+				enqueue(t.rel.Related, resource, t.include)
+			}
+
+			if relationship := t.resource.Base().Relationships[t.rel.Name]; relationship != nil {
+				relationship.SetLinkage(linkages, t.rel.ToMany)
+			}
+		}
+	}
+
+	return included, nil
+}
+
+// resourceKey => identifies a resource across types, e.g. "owners/123"
+func resourceKey(resource resources.JsonApiResourcer) string {
+	return resource.Base().ResourceType + "/" + resource.Base().ID
+}
+
+// sparseIncluded => applies each included resource's fields[type]
+func sparseIncluded(included []resources.JsonApiResourcer, fieldsets map[string][]string) []interface{} {
+	if len(included) == 0 {
+		return nil
+	}
+
+	sparse := make([]interface{}, len(included))
+	for i, resource := range included {
+		sparse[i] = resources.Sparse(resource, fieldsets[resource.Base().ResourceType])
+	}
+	return sparse
+}
+
+// parallel => calls fn(0) .. fn(n-1) on at most workers goroutines and waits for them to finish
+func parallel(n, workers int, fn func(i int)) {
+	if workers > n {
+		workers = n
+	}
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				fn(i)
+			}
+		}()
+	}
+
+	for i := 0; i < n; i++ {
+		indexes <- i
+	}
+	close(indexes)
+
+	wg.Wait()
+}
@@ -0,0 +1,44 @@
+package controllers
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestParallel(t *testing.T) {
+	for _, test := range []struct{ n, workers int }{{0, 4}, {1, 4}, {3, 8}, {50, 4}} {
+		var running, peak int32
+		var mu sync.Mutex
+		calls := make(map[int]int)
+
+		parallel(test.n, test.workers, func(i int) {
+			now := atomic.AddInt32(&running, 1)
+			for {
+				p := atomic.LoadInt32(&peak)
+				if now <= p || atomic.CompareAndSwapInt32(&peak, p, now) {
+					break
+				}
+			}
+			time.Sleep(time.Millisecond)
+			atomic.AddInt32(&running, -1)
+
+			mu.Lock()
+			calls[i]++
+			mu.Unlock()
+		})
+
+		if len(calls) != test.n {
+			t.Errorf("parallel(%d, %d) called fn for %d indexes", test.n, test.workers, len(calls))
+		}
+		for i, count := range calls {
+			if count != 1 || i < 0 || i >= test.n {
+				t.Errorf("parallel(%d, %d) called fn(%d) %d times", test.n, test.workers, i, count)
+			}
+		}
+		if int(peak) > test.workers {
+			t.Errorf("parallel(%d, %d) ran %d calls at once", test.n, test.workers, peak)
+		}
+	}
+}
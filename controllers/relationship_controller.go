@@ -0,0 +1,342 @@
+package controllers
+
+import (
+	"fmt"
+
+	"github.com/go-martini/martini"
+	"github.com/martini-contrib/render"
+	"github.com/obieq/rva-devops-api/db"
+	"github.com/obieq/rva-devops-api/resources"
+)
+
+// Relationship => a named relationship from a controller's resources to another controller's resources,
+// persisted as a graph relation between the two models' collections
+type Relationship struct {
+	// Name => member of the resource's relationships object and route segment, e.g. "owner"
+	Name string
+	// Related => controller of the related resources, e.g. owners
+	Related *ResourceController
+	ToMany  bool
+	// Inverse => name of the relationship on Related that points back, e.g. "automobiles"; "" if none
+	// NOTE: both directions are written together so either side can be traversed
+	Inverse string
+}
+
+// inverse => the relationship pointing back from the related resources, or nil
+func (rel *Relationship) inverse() *Relationship {
+	if rel.Inverse == "" {
+		return nil
+	}
+	return rel.Related.relationships[rel.Inverse]
+}
+
+// AddRelationship => exposes a relationship on the controller's resources
+// NOTE: call before the server starts handling requests; panics on duplicates
+func (c *ResourceController) AddRelationship(rel *Relationship) {
+	if _, dup := c.relationships[rel.Name]; dup {
+		panic("controllers: relationship registered twice: " + c.Type + "." + rel.Name)
+	}
+	if _, clash := c.keys[rel.Name]; clash {
+		panic("controllers: relationship clashes with an attribute: " + c.Type + "." + rel.Name)
+	}
+	c.relationships[rel.Name] = rel
+}
+
+// ShowRelationship => GET /:type/:id/relationships/:name
+func (c *ResourceController) ShowRelationship(rel *Relationship) martini.Handler {
+	return func(args martini.Params, root resources.APIRoot, r render.Render) {
+		id := args["id"]
+
+		if _, err := c.Find(id); err != nil {
+			HandleGetResponse(err, nil, r)
+			return
+		}
+
+		linkages, err := c.linkages(rel, id)
+		if err != nil {
+			HandleRelationError(err, r)
+			return
+		}
+
+		relationship := resources.NewRelationship(root, c.Type, id, rel.Name)
+		relationship.SetLinkage(linkages, rel.ToMany)
+		r.JSON(200, relationship)
+	}
+}
+
+// ShowRelated => GET /:type/:id/:name; the related resource (to-one) or resources (to-many)
+func (c *ResourceController) ShowRelated(rel *Relationship) martini.Handler {
+	return func(args martini.Params, root resources.APIRoot, r render.Render) {
+		id := args["id"]
+
+		if _, err := c.Find(id); err != nil {
+			HandleGetResponse(err, nil, r)
+			return
+		}
+
+		related, err := c.related(rel, id)
+		if err != nil {
+			HandleRelationError(err, r)
+			return
+		}
+
+		data := make([]interface{}, 0, len(related))
+		for _, item := range related {
+			model := rel.Related.NewModel()
+			if err := item.Value(model); err != nil {
+				HandleError(500, err, r)
+				return
+			}
+			data = append(data, rel.Related.ToResource(model, root))
+		}
+
+		links := &resources.Link{Self: root.Join(c.Type, id, rel.Name)}
+		switch {
+		case rel.ToMany:
+			r.JSON(200, map[string]interface{}{"links": links, "data": data})
+		case len(data) == 0:
+			r.JSON(200, map[string]interface{}{"links": links, "data": nil})
+		default:
+			r.JSON(200, map[string]interface{}{"links": links, "data": data[0]})
+		}
+	}
+}
+
+// UpdateRelationship => PATCH /:type/:id/relationships/:name; replaces the relationship's members
+func (c *ResourceController) UpdateRelationship(rel *Relationship) martini.Handler {
+	return func(args martini.Params, request resources.JsonApiRelationshipRequest, r render.Render) {
+		id := args["id"]
+
+		linkages, ok := c.relationshipRequest(rel, id, request, r)
+		if !ok {
+			return
+		}
+
+		current, err := c.linkages(rel, id)
+		if err != nil {
+			HandleRelationError(err, r)
+			return
+		}
+
+		wanted := linkageIDs(linkages)
+		for _, linkage := range current {
+			if !wanted[linkage.ID] {
+				if err := c.unrelate(rel, id, linkage.ID); err != nil {
+					HandleRelationError(err, r)
+					return
+				}
+			}
+		}
+
+		existing := linkageIDs(current)
+		for _, linkage := range linkages {
+			if !existing[linkage.ID] {
+				if err := c.relate(rel, id, linkage.ID); err != nil {
+					HandleRelationError(err, r)
+					return
+				}
+			}
+		}
+
+		r.Status(204)
+	}
+}
+
+// AddToRelationship => POST /:type/:id/relationships/:name; adds members to a to-many relationship
+func (c *ResourceController) AddToRelationship(rel *Relationship) martini.Handler {
+	return func(args martini.Params, request resources.JsonApiRelationshipRequest, r render.Render) {
+		id := args["id"]
+
+		linkages, ok := c.relationshipRequest(rel, id, request, r)
+		if !ok {
+			return
+		}
+
+		for _, linkage := range linkages {
+			if err := c.relate(rel, id, linkage.ID); err != nil {
+				HandleRelationError(err, r)
+				return
+			}
+		}
+
+		r.Status(204)
+	}
+}
+
+// RemoveFromRelationship => DELETE /:type/:id/relationships/:name; removes members from a to-many relationship
+func (c *ResourceController) RemoveFromRelationship(rel *Relationship) martini.Handler {
+	return func(args martini.Params, request resources.JsonApiRelationshipRequest, r render.Render) {
+		id := args["id"]
+
+		linkages, ok := c.relationshipRequest(rel, id, request, r)
+		if !ok {
+			return
+		}
+
+		for _, linkage := range linkages {
+			if err := c.unrelate(rel, id, linkage.ID); err != nil {
+				HandleRelationError(err, r)
+				return
+			}
+		}
+
+		r.Status(204)
+	}
+}
+
+// relationshipRequest => decodes and checks a relationship request's linkages, rendering the error if any
+// NOTE: the resource and every referenced resource must exist
+func (c *ResourceController) relationshipRequest(rel *Relationship, id string, request resources.JsonApiRelationshipRequest, r render.Render) ([]resources.Linkage, bool) {
+	if _, err := c.Find(id); err != nil {
+		HandleGetResponse(err, nil, r)
+		return nil, false
+	}
+
+	linkages, err := request.Linkages(rel.ToMany)
+	if err != nil {
+		HandleError(400, err, r)
+		return nil, false
+	}
+
+	for _, linkage := range linkages {
+		if linkage.Type != rel.Related.Type {
+			HandleError(409, fmt.Errorf("type %q does not match the relationship's type %q", linkage.Type, rel.Related.Type), r)
+			return nil, false
+		}
+		if _, err := rel.Related.Find(linkage.ID); db.KindOf(err) == db.NotFound {
+			HandleError(404, fmt.Errorf("%s %q not found", linkage.Type, linkage.ID), r)
+			return nil, false
+		} else if err != nil {
+			HandleBackendError(err, r)
+			return nil, false
+		}
+	}
+
+	return linkages, true
+}
+
+// relationshipObjects => links to each of the resource's relationships
+func (c *ResourceController) relationshipObjects(root resources.APIRoot, id string) map[string]*resources.Relationship {
+	if len(c.relationships) == 0 || id == "" {
+		return nil
+	}
+
+	objects := make(map[string]*resources.Relationship, len(c.relationships))
+	for name := range c.relationships {
+		objects[name] = resources.NewRelationship(root, c.Type, id, name)
+	}
+	return objects
+}
+
+// loadLinkage => adds the resource linkage to each of a resource's relationship objects
+// NOTE: relationships in skip are left to Include, which loads their linkage along with the related resources
+func (c *ResourceController) loadLinkage(resource resources.JsonApiResourcer, skip resources.Include) error {
+	base := resource.Base()
+
+	for name, relationship := range base.Relationships {
+		if _, ok := skip[name]; ok {
+			continue
+		}
+		linkages, err := c.linkages(c.relationships[name], base.ID)
+		if err != nil {
+			return err
+		}
+		relationship.SetLinkage(linkages, c.relationships[name].ToMany)
+	}
+
+	return nil
+}
+
+// related => the items related to the resource identified by id
+func (c *ResourceController) related(rel *Relationship, id string) ([]db.Related, error) {
+	relater, err := db.Relations()
+	if err != nil {
+		return nil, err
+	}
+
+	return relater.GetRelations(c.collection, id, rel.Name)
+}
+
+// linkages => resource identifiers of the items related to the resource identified by id
+func (c *ResourceController) linkages(rel *Relationship, id string) ([]resources.Linkage, error) {
+	related, err := c.related(rel, id)
+	if err != nil {
+		return nil, err
+	}
+
+	linkages := make([]resources.Linkage, len(related))
+	for i, item := range related {
+		linkages[i] = resources.Linkage{Type: rel.Related.Type, ID: item.Key}
+	}
+	return linkages, nil
+}
+
+// relate => relates id to relatedID in both directions
+// NOTE: a to-one side gives up its previous member first, e.g. an automobile changing owners
+func (c *ResourceController) relate(rel *Relationship, id, relatedID string) error {
+	relater, err := db.Relations()
+	if err != nil {
+		return err
+	}
+
+	inverse := rel.inverse()
+
+	if !rel.ToMany {
+		if err := c.unrelateAll(rel, id); err != nil {
+			return err
+		}
+	}
+	if inverse != nil && !inverse.ToMany {
+		if err := rel.Related.unrelateAll(inverse, relatedID); err != nil {
+			return err
+		}
+	}
+
+	if err := relater.PutRelation(c.collection, id, rel.Name, rel.Related.collection, relatedID); err != nil {
+		return err
+	}
+	if inverse != nil {
+		return relater.PutRelation(rel.Related.collection, relatedID, inverse.Name, c.collection, id)
+	}
+	return nil
+}
+
+// unrelate => removes the relation between id and relatedID in both directions
+func (c *ResourceController) unrelate(rel *Relationship, id, relatedID string) error {
+	relater, err := db.Relations()
+	if err != nil {
+		return err
+	}
+
+	if err := relater.DeleteRelation(c.collection, id, rel.Name, rel.Related.collection, relatedID); err != nil {
+		return err
+	}
+	if inverse := rel.inverse(); inverse != nil {
+		return relater.DeleteRelation(rel.Related.collection, relatedID, inverse.Name, c.collection, id)
+	}
+	return nil
+}
+
+// unrelateAll => empties the relationship of the resource identified by id
+func (c *ResourceController) unrelateAll(rel *Relationship, id string) error {
+	linkages, err := c.linkages(rel, id)
+	if err != nil {
+		return err
+	}
+
+	for _, linkage := range linkages {
+		if err := c.unrelate(rel, id, linkage.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// linkageIDs => set of the linkages' ids
+func linkageIDs(linkages []resources.Linkage) map[string]bool {
+	ids := make(map[string]bool, len(linkages))
+	for _, linkage := range linkages {
+		ids[linkage.ID] = true
+	}
+	return ids
+}
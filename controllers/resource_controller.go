@@ -0,0 +1,362 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"sync"
+
+	"github.com/go-martini/martini"
+	"github.com/martini-contrib/render"
+	goar "github.com/obieq/goar"
+	"github.com/obieq/rva-devops-api/auth"
+	"github.com/obieq/rva-devops-api/db"
+	"github.com/obieq/rva-devops-api/logger"
+	"github.com/obieq/rva-devops-api/resources"
+)
+
+// ResourceController => JSON API handlers for a goar model exposed as a resource
+// NOTE: create one per resource type via NewResourceController; the handlers are safe for concurrent use
+type ResourceController struct {
+	// Type => JSON API resource type and route segment, e.g. "automobiles"
+	Type string
+
+	modelType     reflect.Type // e.g. models.Automobile
+	resourceType  reflect.Type // e.g. resources.Automobile
+	keys          map[string]resources.ModelKey
+	collection    string // model's collection, e.g. "automobiles"
+	relationships map[string]*Relationship
+}
+
+var (
+	controllersMu sync.RWMutex
+	controllers   = make(map[string]*ResourceController)
+)
+
+// NewResourceController => handlers for model (e.g. models.Automobile{}) exposed as resource (e.g. resources.Automobile{})
+// NOTE: panics if *model is not a paged goar model or *resource does not embed resources.BaseResource
+func NewResourceController(resourceType string, model, resource interface{}) *ResourceController {
+	c := &ResourceController{
+		Type:          resourceType,
+		modelType:     reflect.Indirect(reflect.ValueOf(model)).Type(),
+		resourceType:  reflect.Indirect(reflect.ValueOf(resource)).Type(),
+		relationships: make(map[string]*Relationship),
+	}
+
+	if _, ok := reflect.New(c.modelType).Interface().(CollectionModel); !ok {
+		panic(fmt.Sprintf("controllers: *%v is not a goar model with paging (embed models.BaseModel)", c.modelType))
+	}
+	if _, ok := reflect.New(c.resourceType).Interface().(resources.JsonApiResourcer); !ok {
+		panic(fmt.Sprintf("controllers: *%v is not a resource (embed resources.BaseResource)", c.resourceType))
+	}
+	c.keys = resources.AttributeKeys(reflect.New(c.resourceType).Interface(), reflect.New(c.modelType).Interface())
+	c.collection = c.NewModel().ModelName()
+
+	return c
+}
+
+// AddController => makes a controller discoverable by its resource type
+func AddController(c *ResourceController) {
+	controllersMu.Lock()
+	defer controllersMu.Unlock()
+
+	if _, dup := controllers[c.Type]; dup {
+		panic("controllers: resource type registered twice: " + c.Type)
+	}
+	controllers[c.Type] = c
+}
+
+// LookupController => the controller registered for a resource type
+func LookupController(resourceType string) (*ResourceController, bool) {
+	controllersMu.RLock()
+	defer controllersMu.RUnlock()
+
+	c, ok := controllers[resourceType]
+	return c, ok
+}
+
+// ResourceTypes => sorted resource types of the registered controllers
+func ResourceTypes() []string {
+	controllersMu.RLock()
+	defer controllersMu.RUnlock()
+
+	types := make([]string, 0, len(controllers))
+	for t := range controllers {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+
+	return types
+}
+
+// NewModel => an empty active record model of the controller's type
+func (c *ResourceController) NewModel() CollectionModel {
+	model := reflect.New(c.modelType).Interface().(CollectionModel)
+	goar.ToAR(model)
+	return model
+}
+
+// NewResource => an empty resource of the controller's type
+func (c *ResourceController) NewResource() resources.JsonApiResourcer {
+	return reflect.New(c.resourceType).Interface().(resources.JsonApiResourcer)
+}
+
+// ToResource => maps a model to a resource with links, including links to its relationships
+func (c *ResourceController) ToResource(model interface{}, root resources.APIRoot) resources.JsonApiResourcer {
+	resource := c.NewResource()
+	resources.MapFromModel(c.Type, resource, model)
+	resource.BuildLinks(root)
+	resource.Base().Relationships = c.relationshipObjects(root, resource.Base().ID)
+	return resource
+}
+
+// Find => loads a model by id
+func (c *ResourceController) Find(id string) (goar.ActiveRecordInterfacer, error) {
+	result, err := c.NewModel().Find(id)
+	if err != nil {
+		return nil, err
+	}
+	return result.(goar.ActiveRecordInterfacer), nil
+}
+
+// Index => GET /:type
+func (c *ResourceController) Index(req *http.Request, root resources.APIRoot, r render.Render) {
+	var data, included []interface{}
+
+	q, err := resources.ParseCollectionQuery(req.URL.Query(), c.Type, c.keys)
+	if err != nil {
+		HandleRequestError(err, r)
+		return
+	}
+	include, fieldsets, err := c.ParseIncludeQuery(req.URL.Query())
+	if err != nil {
+		HandleRequestError(err, r)
+		return
+	}
+
+	dbModels := reflect.New(reflect.SliceOf(c.modelType))
+	dbModels.Elem().Set(reflect.MakeSlice(reflect.SliceOf(c.modelType), 0, q.Page.Size))
+	links, err := FindCollection(c.NewModel(), q, root.Join(c.Type), dbModels.Interface())
+
+	// map the models to resources
+	if err == nil {
+		primary := make([]resources.JsonApiResourcer, dbModels.Elem().Len())
+		for i := range primary {
+			primary[i] = c.ToResource(dbModels.Elem().Index(i).Addr().Interface(), root)
+		}
+
+		if include != nil {
+			related, err := c.Include(primary, include, root)
+			if err != nil {
+				HandleRelationError(err, r)
+				return
+			}
+			included = sparseIncluded(related, fieldsets)
+		}
+
+		data = make([]interface{}, len(primary))
+		for i, resource := range primary {
+			data[i] = resources.Sparse(resource, q.Fields)
+		}
+	}
+
+	// a page has no single ref or modification time, so it is validated by its contents
+	if err == nil {
+		document := resources.CollectionDocument{Links: links, Meta: q.Page.Meta(), Data: data, Included: included}
+		if NotModified(req, WeakETag(document), nil, r) {
+			return
+		}
+	}
+
+	HandleIndexResponse(err, links, q.Page.Meta(), data, included, r)
+}
+
+// Show => GET /:type/:id
+func (c *ResourceController) Show(args martini.Params, req *http.Request, root resources.APIRoot, r render.Render) {
+	var resource interface{}
+	var included []interface{}
+
+	fields, err := resources.ParseFieldset(req.URL.Query(), c.Type, c.keys)
+	if err != nil {
+		HandleRequestError(err, r)
+		return
+	}
+	include, fieldsets, err := c.ParseIncludeQuery(req.URL.Query())
+	if err != nil {
+		HandleRequestError(err, r)
+		return
+	}
+
+	model, err := c.Find(args["id"])
+
+	// map the model to the resource, including the linkage of its relationships
+	if err == nil {
+		full := c.ToResource(model, root)
+		if err := c.loadLinkage(full, include); err != nil {
+			HandleRelationError(err, r)
+			return
+		}
+
+		if include != nil {
+			related, err := c.Include([]resources.JsonApiResourcer{full}, include, root)
+			if err != nil {
+				HandleRelationError(err, r)
+				return
+			}
+			included = sparseIncluded(related, fieldsets)
+		}
+
+		resource = resources.Sparse(full, fields)
+
+		// included resources change independently of this one, so only the whole document can validate them
+		if include != nil {
+			if NotModified(req, WeakETag(resources.Document{Data: resource, Included: included}), nil, r) {
+				return
+			}
+		} else if NotModified(req, ModelETag(model), LastModified(full), r) {
+			return
+		}
+	}
+	HandleShowResponse(err, resource, included, r)
+}
+
+// Create => POST /:type
+func (c *ResourceController) Create(request resources.JsonApiRequest, principal *auth.Principal, root resources.APIRoot, r render.Render) {
+	resource := c.NewResource()
+	if err := json.Unmarshal(request.Data, resource); err != nil {
+		HandleError(400, err, r)
+		return
+	}
+	if t := resource.Base().ResourceType; t != "" && t != c.Type {
+		HandleError(409, fmt.Errorf("type %q does not match the endpoint's type %q", t, c.Type), r)
+		return
+	}
+
+	// map the resource to the model
+	model := c.NewModel()
+	resources.MapToModel(resource, model)
+	attribute(model, principal)
+
+	// persist the model
+	success, err := model.Save()
+
+	// map the model to the resource
+	if err == nil {
+		resource = c.ToResource(model, root)
+	}
+	if success {
+		SetETag(model, r)
+	}
+
+	// process result
+	HandlePostResponse(success, err, resource, r)
+}
+
+// Update => PUT /:type/:id; replaces every attribute
+// NOTE: honors If-Match; the save fails with 412 if the resource changes between Find and Save either way
+func (c *ResourceController) Update(args martini.Params, req *http.Request, request resources.JsonApiRequest, principal *auth.Principal, root resources.APIRoot, r render.Render) {
+	resource := c.NewResource()
+	if err := json.Unmarshal(request.Data, resource); err != nil {
+		HandleError(400, err, r)
+		return
+	}
+
+	model, err := c.Find(args["id"])
+	if err != nil { // get failed, so re-use the get response method, which properly handles the error condition
+		HandleGetResponse(err, nil, r)
+		return
+	}
+	if !CheckIfMatch(req, model, r) {
+		return
+	}
+
+	// update properties
+	resources.MapToModel(resource, model)
+	attribute(model, principal)
+
+	// persist changes
+	success, err := model.Save()
+
+	// map the model to the resource
+	if err == nil {
+		resource = c.ToResource(model, root)
+	}
+	if success {
+		SetETag(model, r)
+	}
+
+	// process result
+	HandlePutPatchResponse(success, err, resource, r)
+}
+
+// Patch => PATCH /:type/:id; only changes the attributes present in the request
+// NOTE: honors If-Match, like Update
+func (c *ResourceController) Patch(args martini.Params, req *http.Request, request resources.JsonApiPatchRequest, principal *auth.Principal, root resources.APIRoot, r render.Render) {
+	model, err := c.Find(args["id"])
+	if err != nil { // get failed, so re-use the get response method, which properly handles the error condition
+		HandleGetResponse(err, nil, r)
+		return
+	}
+	if !CheckIfMatch(req, model, r) {
+		return
+	}
+
+	// apply only the attributes present in the request
+	resource := c.ToResource(model, root)
+	err = resources.PatchAttributes(resource, request.Data)
+	if HandlePatchRequestErrors(request, c.Type, args["id"], err, r) {
+		return
+	}
+	resources.MapToModel(resource, model)
+	attribute(model, principal)
+
+	// persist changes
+	success, err := model.Save()
+
+	// map the model to the resource
+	if err == nil {
+		resource = c.ToResource(model, root)
+	}
+	if success {
+		SetETag(model, r)
+	}
+
+	// process result
+	HandlePutPatchResponse(success, err, resource, r)
+}
+
+// Delete => DELETE /:type/:id
+// NOTE: the resource is loaded first, so its history records what was deleted and the delete is conditional
+// on its ref (honoring If-Match); its relations are removed too, so related resources do not keep linkage
+// to a deleted resource
+func (c *ResourceController) Delete(args martini.Params, req *http.Request, principal *auth.Principal, r render.Render) {
+	model, err := c.Find(args["id"])
+	if err != nil {
+		HandleGetResponse(err, nil, r)
+		return
+	}
+	if !CheckIfMatch(req, model, r) {
+		return
+	}
+	attribute(model, principal)
+
+	if len(c.relationships) == 0 {
+		HandleDeleteResponse(model, r)
+		return
+	}
+
+	// delete before unrelating, so a failed (e.g. conditional) delete leaves the relations intact
+	goar.ToAR(model)
+	if err := model.Delete(); err != nil {
+		HandleBackendError(err, r)
+		return
+	}
+	for _, rel := range c.relationships {
+		if err := c.unrelateAll(rel, args["id"]); err != nil && err != db.ErrRelationsUnsupported {
+			logger.Warn("delete", c.Type, args["id"], "left relation", rel.Name, "behind:", err)
+		}
+	}
+	r.Status(204)
+}
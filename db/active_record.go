@@ -0,0 +1,250 @@
+package db
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+
+	goar "github.com/obieq/goar"
+	"github.com/obieq/rva-devops-api/logger"
+)
+
+// Versioned => implemented by models that track the ref of their stored value (see ArBackend)
+type Versioned interface {
+	Ref() string
+	SetRef(ref string)
+}
+
+// ArBackend => goar adapter that persists through the backend selected at startup
+// NOTE: saves and deletes are compare-and-swap whenever the model knows its ref, i.e. after Find or Save;
+// SetRef("") opts out, SetRef(ref) makes the write conditional on a ref obtained elsewhere. Every save and
+// delete is recorded in the model's history (see Change)
+type ArBackend struct {
+	goar.ActiveRecord
+	ID string `json:"id,omitempty"`
+	goar.Timestamps
+	ref string
+	// stored => the model as loaded by Find or last saved; the "before" of its next change
+	stored json.RawMessage
+	// principal, keyID => who is changing the model (see SetPrincipal)
+	principal string
+	keyID     string
+}
+
+// SetKey => sets the model's primary key
+func (ar *ArBackend) SetKey(key string) {
+	ar.ID = key
+}
+
+// Ref => ref of the stored value the model was loaded from or last saved as; "" if unknown
+func (ar *ArBackend) Ref() string {
+	return ar.ref
+}
+
+// SetRef => sets the ref that the next save or delete must match
+func (ar *ArBackend) SetRef(ref string) {
+	ar.ref = ref
+}
+
+// historied => implemented by every model that embeds ArBackend
+type historied interface {
+	setStored(stored json.RawMessage)
+}
+
+func (ar *ArBackend) setStored(stored json.RawMessage) {
+	ar.stored = stored
+}
+
+// SetPrincipal => names who is changing the model, for its history
+func (ar *ArBackend) SetPrincipal(name, keyID string) {
+	ar.principal, ar.keyID = name, keyID
+}
+
+// AfterSave => appends the change to the model's history; goar calls it after every successful Save
+// NOTE: goar only logs the error, since the save itself succeeded
+func (ar *ArBackend) AfterSave() error {
+	after, err := json.Marshal(ar.Self())
+	if err != nil {
+		return err
+	}
+
+	action := UPDATE_ACTION
+	if ar.UpdatedAt == nil {
+		action = CREATE_ACTION
+	}
+	change := Change{Action: action, Principal: ar.principal, KeyID: ar.keyID, Before: ar.stored, After: after}
+	ar.stored = after
+
+	return record(ar.ModelName(), ar.ID, change)
+}
+
+// All => loads the collection into models, which must be a pointer to a slice
+// Supported opts: "limit" (int), "afterKey" (string), "startKey" (string)
+func (ar *ArBackend) All(models interface{}, opts map[string]interface{}) error {
+	_, err := ar.AllPage(models, opts)
+	return err
+}
+
+// AllPage => same as All, but also returns the cursors of the surrounding pages
+func (ar *ArBackend) AllPage(models interface{}, opts map[string]interface{}) (page Page, err error) {
+	start := time.Now()
+	defer func() { observe("List", ar.ModelName(), start, err) }()
+
+	backend := Current()
+	if backend == nil {
+		return Page{}, ErrNoBackend
+	}
+
+	limit := 0
+	afterKey, startKey := "", ""
+
+	if v, ok := opts["limit"].(int); ok {
+		limit = v
+	}
+	if v, ok := opts["afterKey"].(string); ok {
+		afterKey = v
+	}
+	if v, ok := opts["startKey"].(string); ok {
+		startKey = v
+	}
+
+	return backend.List(ar.ModelName(), limit, afterKey, startKey, models)
+}
+
+// Find => loads the model identified by id into a new instance of the active record's type
+func (ar *ArBackend) Find(id interface{}) (result interface{}, err error) {
+	start := time.Now()
+	defer func() { observe("Find", ar.ModelName(), start, err) }()
+
+	backend := Current()
+	if backend == nil {
+		return nil, ErrNoBackend
+	}
+
+	key, ok := id.(string)
+	if !ok {
+		return nil, errors.New("db: id must be a string")
+	}
+
+	modelVal := reflect.ValueOf(ar.Self()).Elem()
+	model := reflect.New(modelVal.Type()).Interface().(goar.ActiveRecordInterfacer)
+
+	ref, err := backend.Get(ar.ModelName(), key, model)
+	if err != nil {
+		return nil, err
+	}
+	model.(Versioned).SetRef(ref)
+	if stored, err := json.Marshal(model); err == nil {
+		model.(historied).setStored(stored)
+	}
+
+	return goar.ToAR(model), nil
+}
+
+// Truncate => removes every model in the collection
+func (ar *ArBackend) Truncate() (numRowsDeleted int, err error) {
+	backend := Current()
+	if backend == nil {
+		return 0, ErrNoBackend
+	}
+
+	return backend.Truncate(ar.ModelName())
+}
+
+// DbSave => inserts the model on create and replaces it on update, conditionally if its ref is known
+// NOTE: goar sets UpdatedAt on updates only, so a nil UpdatedAt means the model is new
+func (ar *ArBackend) DbSave() (err error) {
+	start := time.Now()
+	defer func() { observe("Save", ar.ModelName(), start, err) }()
+
+	backend := Current()
+	if backend == nil {
+		return ErrNoBackend
+	}
+
+	var ref string
+	switch {
+	case ar.UpdatedAt == nil:
+		ref, err = backend.PutIfAbsent(ar.ModelName(), ar.ID, ar.Self())
+	case ar.ref != "":
+		ref, err = backend.PutIfUnmodified(ar.ModelName(), ar.ID, ar.ref, ar.Self())
+	default:
+		ref, err = backend.Put(ar.ModelName(), ar.ID, ar.Self())
+	}
+
+	if err == nil {
+		ar.ref = ref
+	}
+	return err
+}
+
+// DbDelete => removes the model, conditionally if its ref is known, and records the change in its history
+// NOTE: Orchestrate purges the events of items deleted unconditionally, so load the model via Find first
+func (ar *ArBackend) DbDelete() (err error) {
+	start := time.Now()
+	defer func() { observe("Delete", ar.ModelName(), start, err) }()
+
+	backend := Current()
+	if backend == nil {
+		return ErrNoBackend
+	}
+
+	if ar.ref != "" {
+		err = backend.DeleteIfUnmodified(ar.ModelName(), ar.ID, ar.ref)
+	} else {
+		err = backend.Delete(ar.ModelName(), ar.ID)
+	}
+	if err != nil {
+		return err
+	}
+
+	// like AfterSave, a failure to record the change does not fail the delete
+	change := Change{Action: DELETE_ACTION, Principal: ar.principal, KeyID: ar.keyID, Before: ar.stored}
+	if err := record(ar.ModelName(), ar.ID, change); err != nil {
+		logger.Warn("history:", ar.ModelName(), ar.ID, "=>", err)
+	}
+	return nil
+}
+
+// DbSearch => runs the active record's Where/Order/Pluck query against the collection
+func (ar *ArBackend) DbSearch(models interface{}) (err error) {
+	start := time.Now()
+	defer func() { observe("Search", ar.ModelName(), start, err) }()
+
+	backend := Current()
+	if backend == nil {
+		return ErrNoBackend
+	}
+
+	q, err := QueryFrom(ar.Query())
+	if err != nil {
+		return err
+	}
+
+	return backend.Search(ar.ModelName(), q, models)
+}
+
+// QueryFrom => converts a goar query into backend-neutral search parameters
+func QueryFrom(query *goar.Query) (q Query, err error) {
+	q.Where = query.WhereConditions
+	q.Order = query.OrderBys
+
+	for _, key := range query.Plucks {
+		q.Pluck = append(q.Pluck, fmt.Sprintf("%v", key))
+	}
+	if query.Limit != "" {
+		if q.Limit, err = strconv.Atoi(query.Limit); err != nil {
+			return q, err
+		}
+	}
+	if query.Offset != "" {
+		if q.Offset, err = strconv.Atoi(query.Offset); err != nil {
+			return q, err
+		}
+	}
+
+	return q, nil
+}
@@ -0,0 +1,133 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	goar "github.com/obieq/goar"
+)
+
+// ErrNoBackend => returned by models when no backend has been selected via Use
+var ErrNoBackend = errors.New("db: no storage backend selected")
+
+// Query => backend-neutral search parameters derived from a goar query
+type Query struct {
+	Where  []goar.QueryCondition
+	Order  []goar.OrderBy
+	Pluck  []string
+	Limit  int
+	Offset int
+}
+
+// Page => cursors that surround a page of List results
+type Page struct {
+	// NextKey => afterKey of the following page; "" if this is the last page
+	NextKey string
+	// PrevKey => afterKey of the preceding page ("" is the first page); only meaningful when HasPrev is true
+	PrevKey string
+	HasPrev bool
+}
+
+// Backend => storage operations a goar model needs, independent of the database behind them
+// NOTE: values are (un)marshaled as JSON documents keyed by collection + key; each stored value is
+// identified by a ref (e.g. Orchestrate's Path.Ref) that changes whenever the value does
+type Backend interface {
+	Get(collection, key string, v interface{}) (ref string, err error)
+	Put(collection, key string, v interface{}) (ref string, err error)
+	PutIfAbsent(collection, key string, v interface{}) (ref string, err error)
+	// PutIfUnmodified => fails with PreconditionFailed unless the stored value's ref is still ref
+	PutIfUnmodified(collection, key, ref string, v interface{}) (string, error)
+	Delete(collection, key string) error
+	// DeleteIfUnmodified => fails with PreconditionFailed unless the stored value's ref is still ref
+	DeleteIfUnmodified(collection, key, ref string) error
+	Truncate(collection string) (int, error)
+	// List => afterKey is exclusive, startKey is inclusive; v must be a pointer to a slice
+	// NOTE: backends that cannot page backwards leave Page.HasPrev false
+	List(collection string, limit int, afterKey, startKey string, v interface{}) (Page, error)
+	// Search => v must be a pointer to a slice
+	Search(collection string, q Query, v interface{}) error
+	Close() error
+}
+
+// Factory => builds a backend from string options (e.g. "api_key")
+type Factory func(opts map[string]string) (Backend, error)
+
+var (
+	mu        sync.RWMutex
+	factories = make(map[string]Factory)
+	current   Backend
+)
+
+// UNPORTED_GOAR_ADAPTERS => goar adapters that have no Backend yet, and why
+// NOTE: goar's adapters are model embeds (e.g. rethinkdb.ArRethinkDb) rather than storage backends; each one
+// connects from its package's init() using its own env vars (rethinkdb panics when that fails), and their
+// drivers are not vendored under Godeps. Porting one means implementing Backend on top of its driver and
+// calling Register, as db/memory and db/orchestrate do
+var UNPORTED_GOAR_ADAPTERS = map[string]string{
+	"rethinkdb": "needs github.com/dancannon/gorethink",
+	"dynamodb":  "needs github.com/AdRoll/goamz",
+	"couchbase": "needs github.com/couchbaselabs/go-couchbase",
+	"cloudant":  "needs github.com/obieq/go-cloudant",
+}
+
+// Register => makes a backend available by name
+// NOTE: intended to be called from a backend package's init(); panics on duplicates
+func Register(name string, factory Factory) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if factory == nil {
+		panic("db: Register factory is nil")
+	}
+	if _, dup := factories[name]; dup {
+		panic("db: Register called twice for backend " + name)
+	}
+	factories[name] = factory
+}
+
+// Backends => sorted names of the registered backends
+func Backends() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	names := make([]string, 0, len(factories))
+	for name := range factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Open => builds the named backend
+func Open(name string, opts map[string]string) (Backend, error) {
+	mu.RLock()
+	factory, ok := factories[name]
+	mu.RUnlock()
+
+	if reason, unported := UNPORTED_GOAR_ADAPTERS[name]; !ok && unported {
+		return nil, fmt.Errorf("db: goar's %s adapter has not been ported to a Backend yet (%s); registered: %v", name, reason, Backends())
+	}
+	if !ok {
+		return nil, fmt.Errorf("db: unknown backend %q (registered: %v)", name, Backends())
+	}
+
+	return factory(opts)
+}
+
+// Use => selects the backend used by every model
+func Use(backend Backend) {
+	mu.Lock()
+	current = backend
+	mu.Unlock()
+}
+
+// Current => the backend selected via Use, or nil
+func Current() Backend {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	return current
+}
@@ -0,0 +1,64 @@
+package db
+
+// ErrorKind => backend-neutral classification of a storage failure
+type ErrorKind int
+
+const (
+	// Unknown => not classified by the backend; treat as an internal error
+	Unknown ErrorKind = iota
+	// NotFound => the key does not exist
+	NotFound
+	// Conflict => the key already exists, e.g. PutIfAbsent on a taken key
+	Conflict
+	// PreconditionFailed => a conditional write lost a race with another writer
+	PreconditionFailed
+	// Unavailable => the backend could not be reached or is overloaded; the request may be retried
+	Unavailable
+	// Unauthorized => the backend rejected the API's credentials
+	Unauthorized
+)
+
+var errorKindNames = map[ErrorKind]string{
+	Unknown:            "unknown",
+	NotFound:           "not found",
+	Conflict:           "conflict",
+	PreconditionFailed: "precondition failed",
+	Unavailable:        "unavailable",
+	Unauthorized:       "unauthorized",
+}
+
+func (k ErrorKind) String() string {
+	return errorKindNames[k]
+}
+
+// Error => a backend error classified by Kind
+// NOTE: backends return *Error so that models and controllers never inspect backend-specific errors
+type Error struct {
+	Kind ErrorKind
+	// Err => the backend's own error
+	Err error
+}
+
+// NewError => classifies err; nil stays nil
+func NewError(kind ErrorKind, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &Error{Kind: kind, Err: err}
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+// KindOf => the kind of a backend error; Unknown for errors no backend classified
+func KindOf(err error) ErrorKind {
+	switch t := err.(type) {
+	case *Error:
+		return t.Kind
+	}
+	if err == ErrNoBackend {
+		return Unavailable
+	}
+	return Unknown
+}
@@ -0,0 +1,46 @@
+package db
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// ErrEventsUnsupported => returned when the selected backend cannot store events
+var ErrEventsUnsupported = errors.New("db: storage backend does not support events")
+
+// Event => a value appended to an item's timeline
+type Event struct {
+	Timestamp time.Time
+	// Ordinal => tells apart events recorded with the same timestamp
+	Ordinal  uint64
+	RawValue json.RawMessage
+}
+
+// Value => unmarshals the event's value into v
+func (e Event) Value(v interface{}) error {
+	return json.Unmarshal(e.RawValue, v)
+}
+
+// Eventer => optional Backend capability for timelines of events attached to items, by kind
+// NOTE: events may be attached to keys whose item has been deleted
+type Eventer interface {
+	PutEvent(collection, key, kind string, v interface{}) error
+	// GetEventsInRange => up to limit events of kind recorded from start up to (excluding) end, newest first
+	GetEventsInRange(collection, key, kind string, start, end time.Time, limit int) ([]Event, error)
+}
+
+// Events => the current backend's Eventer
+func Events() (Eventer, error) {
+	backend := Current()
+	if backend == nil {
+		return nil, ErrNoBackend
+	}
+
+	eventer, ok := backend.(Eventer)
+	if !ok {
+		return nil, ErrEventsUnsupported
+	}
+
+	return eventer, nil
+}
@@ -0,0 +1,47 @@
+package db
+
+import (
+	"encoding/json"
+
+	"github.com/obieq/rva-devops-api/logger"
+)
+
+// HISTORY_EVENT => event kind of the changes recorded for every model (see ArBackend.AfterSave)
+const HISTORY_EVENT string = "history"
+
+// Change actions
+const (
+	CREATE_ACTION string = "create"
+	UPDATE_ACTION string = "update"
+	DELETE_ACTION string = "delete"
+)
+
+// Change => an entry of a model's history: what it looked like before and after, and who changed it
+// NOTE: Before is empty for creates and for saves of models that were not loaded via Find; After is empty for deletes
+type Change struct {
+	Action string `json:"action"`
+	// Principal, KeyID => who made the change (see Attributable); empty when it was not made through the API
+	Principal string          `json:"principal,omitempty"`
+	KeyID     string          `json:"key_id,omitempty"`
+	Before    json.RawMessage `json:"before,omitempty"`
+	After     json.RawMessage `json:"after,omitempty"`
+}
+
+// Attributable => implemented by models that record who changes them in their history (see ArBackend)
+type Attributable interface {
+	SetPrincipal(name, keyID string)
+}
+
+// record => appends change to the history of collection/key
+// NOTE: backends without events keep no history
+func record(collection, key string, change Change) error {
+	eventer, err := Events()
+	if err == ErrEventsUnsupported {
+		logger.Debug("history:", err)
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	return eventer.PutEvent(collection, key, HISTORY_EVENT, change)
+}
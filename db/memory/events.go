@@ -0,0 +1,42 @@
+package memory
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/obieq/rva-devops-api/db"
+)
+
+// PutEvent => appends an event, timestamped now, to the item's timeline
+func (s *Store) PutEvent(collection, key, kind string, v interface{}) error {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.ordinal++
+	k := relationKey(collection, key, kind)
+	s.events[k] = append(s.events[k], db.Event{Timestamp: time.Now().UTC(), Ordinal: s.ordinal, RawValue: raw})
+
+	return nil
+}
+
+// GetEventsInRange => events between start and end, newest first
+func (s *Store) GetEventsInRange(collection, key, kind string, start, end time.Time, limit int) ([]db.Event, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	timeline := s.events[relationKey(collection, key, kind)]
+
+	var events []db.Event
+	for i := len(timeline) - 1; i >= 0 && len(events) < limit; i-- {
+		if e := timeline[i]; !e.Timestamp.Before(start) && e.Timestamp.Before(end) {
+			events = append(events, e)
+		}
+	}
+
+	return events, nil
+}
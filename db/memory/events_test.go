@@ -0,0 +1,57 @@
+package memory
+
+import (
+	"testing"
+	"time"
+
+	"github.com/obieq/rva-devops-api/db"
+)
+
+func TestGetEventsInRange(t *testing.T) {
+	s := NewStore()
+	start := time.Now().UTC()
+	for i := 1; i <= 5; i++ {
+		if err := s.PutEvent("cars", "c", "history", map[string]int{"n": i}); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(2 * time.Millisecond)
+	}
+	s.PutEvent("cars", "d", "history", map[string]int{"n": 6})
+	s.PutEvent("cars", "c", "other", map[string]int{"n": 7})
+	end := time.Now().UTC().Add(time.Millisecond)
+
+	numbers := func(events []db.Event) []int {
+		var ns []int
+		for i, event := range events {
+			var v struct{ N int }
+			if err := event.Value(&v); err != nil {
+				t.Fatal(err)
+			}
+			ns = append(ns, v.N)
+			if i > 0 && (event.Ordinal >= events[i-1].Ordinal || event.Timestamp.After(events[i-1].Timestamp)) {
+				t.Errorf("event %d (ordinal %d) follows event %d (ordinal %d)", v.N, event.Ordinal, ns[i-1], events[i-1].Ordinal)
+			}
+		}
+		return ns
+	}
+
+	all, _ := s.GetEventsInRange("cars", "c", "history", start, end, 100)
+	if got := numbers(all); len(got) != 5 || got[0] != 5 || got[4] != 1 {
+		t.Fatalf("all events %v, want 5 to 1", got)
+	}
+
+	limited, _ := s.GetEventsInRange("cars", "c", "history", start, end, 2)
+	if got := numbers(limited); len(got) != 2 || got[0] != 5 || got[1] != 4 {
+		t.Errorf("limited to 2: %v, want 5 and 4", got)
+	}
+
+	// start is inclusive, end exclusive
+	ranged, _ := s.GetEventsInRange("cars", "c", "history", all[3].Timestamp, all[1].Timestamp, 100)
+	if got := numbers(ranged); len(got) != 2 || got[0] != 3 || got[1] != 2 {
+		t.Errorf("from event 2 up to event 4: %v, want 3 and 2", got)
+	}
+
+	if none, _ := s.GetEventsInRange("cars", "missing", "history", start, end, 100); len(none) != 0 {
+		t.Errorf("events of a missing item: %v", numbers(none))
+	}
+}
@@ -0,0 +1,74 @@
+package memory
+
+import (
+	"encoding/json"
+
+	"github.com/obieq/rva-devops-api/db"
+)
+
+// endpoint => the sink of a relation
+type endpoint struct {
+	collection string
+	key        string
+}
+
+func relationKey(collection, key, kind string) string {
+	return collection + "/" + key + "/" + kind
+}
+
+// PutRelation => relates source to sink; relating the same pair twice is a no-op
+func (s *Store) PutRelation(sourceCollection, sourceKey, kind, sinkCollection, sinkKey string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	k := relationKey(sourceCollection, sourceKey, kind)
+	sink := endpoint{collection: sinkCollection, key: sinkKey}
+
+	for _, e := range s.relations[k] {
+		if e == sink {
+			return nil
+		}
+	}
+	s.relations[k] = append(s.relations[k], sink)
+
+	return nil
+}
+
+// DeleteRelation => removes the relation between source and sink, if any
+func (s *Store) DeleteRelation(sourceCollection, sourceKey, kind, sinkCollection, sinkKey string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	k := relationKey(sourceCollection, sourceKey, kind)
+	sink := endpoint{collection: sinkCollection, key: sinkKey}
+
+	sinks := s.relations[k]
+	for i, e := range sinks {
+		if e == sink {
+			s.relations[k] = append(sinks[:i:i], sinks[i+1:]...)
+			break
+		}
+	}
+	if len(s.relations[k]) == 0 {
+		delete(s.relations, k)
+	}
+
+	return nil
+}
+
+// GetRelations => the items related to collection/key via kind
+func (s *Store) GetRelations(collection, key, kind string) ([]db.Related, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var results []db.Related
+	for _, e := range s.relations[relationKey(collection, key, kind)] {
+		raw, ok := s.collections[e.collection][e.key]
+		if !ok {
+			continue
+		}
+		results = append(results, db.Related{Collection: e.collection, Key: e.key, RawValue: json.RawMessage(raw)})
+	}
+
+	return results, nil
+}
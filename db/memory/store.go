@@ -0,0 +1,406 @@
+package memory
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	goar "github.com/obieq/goar"
+	"github.com/obieq/rva-devops-api/db"
+)
+
+var (
+	// ErrNotFound => returned when a key does not exist within a collection
+	ErrNotFound = db.NewError(db.NotFound, errors.New("memory: record not found"))
+	// ErrAlreadyExists => returned by PutIfAbsent when a key is already taken
+	ErrAlreadyExists = db.NewError(db.Conflict, errors.New("memory: record already exists"))
+	// ErrModified => returned by conditional writes when the stored value's ref no longer matches
+	ErrModified = db.NewError(db.PreconditionFailed, errors.New("memory: record has been modified"))
+)
+
+// Store => concurrency-safe, in-process db.Backend organized by collection
+// NOTE: data does not survive a restart; intended for local development, demos and tests
+type Store struct {
+	mu          sync.RWMutex
+	collections map[string]map[string][]byte
+	relations   map[string][]endpoint // "collection/key/kind" => sinks, in insertion order
+	events      map[string][]db.Event // "collection/key/kind" => timeline, oldest first
+	ordinal     uint64
+}
+
+func init() {
+	db.Register("memory", func(opts map[string]string) (db.Backend, error) {
+		return NewStore(), nil
+	})
+}
+
+// NewStore => creates an empty store
+func NewStore() *Store {
+	return &Store{
+		collections: make(map[string]map[string][]byte),
+		relations:   make(map[string][]endpoint),
+		events:      make(map[string][]db.Event),
+	}
+}
+
+// Close => no-op; satisfies db.Backend
+func (s *Store) Close() error {
+	return nil
+}
+
+// Ping => always succeeds, the data is in process; satisfies db.Pinger
+func (s *Store) Ping() error {
+	return nil
+}
+
+// Get => unmarshals the value stored under collection/key into v
+func (s *Store) Get(collection, key string, v interface{}) (string, error) {
+	s.mu.RLock()
+	raw, ok := s.collections[collection][key]
+	s.mu.RUnlock()
+
+	if !ok {
+		return "", ErrNotFound
+	}
+
+	return refOf(raw), json.Unmarshal(raw, v)
+}
+
+// Put => stores v under collection/key, replacing any existing value
+func (s *Store) Put(collection, key string, v interface{}) (string, error) {
+	return s.put(collection, key, v, false, "")
+}
+
+// PutIfAbsent => stores v under collection/key only if the key is not already taken
+func (s *Store) PutIfAbsent(collection, key string, v interface{}) (string, error) {
+	return s.put(collection, key, v, true, "")
+}
+
+// PutIfUnmodified => replaces the value under collection/key only if its ref is still ref
+func (s *Store) PutIfUnmodified(collection, key, ref string, v interface{}) (string, error) {
+	return s.put(collection, key, v, false, ref)
+}
+
+func (s *Store) put(collection, key string, v interface{}, ifAbsent bool, ifRef string) (string, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.collections[collection]
+	if !ok {
+		c = make(map[string][]byte)
+		s.collections[collection] = c
+	}
+
+	current, exists := c[key]
+	switch {
+	case exists && ifAbsent:
+		return "", ErrAlreadyExists
+	case ifRef != "" && !exists:
+		return "", ErrNotFound
+	case ifRef != "" && refOf(current) != ifRef:
+		return "", ErrModified
+	}
+	c[key] = raw
+
+	return refOf(raw), nil
+}
+
+// Delete => removes collection/key
+func (s *Store) Delete(collection, key string) error {
+	return s.delete(collection, key, "")
+}
+
+// DeleteIfUnmodified => removes collection/key only if its ref is still ref
+func (s *Store) DeleteIfUnmodified(collection, key, ref string) error {
+	return s.delete(collection, key, ref)
+}
+
+func (s *Store) delete(collection, key, ifRef string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current, ok := s.collections[collection][key]
+	if !ok {
+		return ErrNotFound
+	}
+	if ifRef != "" && refOf(current) != ifRef {
+		return ErrModified
+	}
+	delete(s.collections[collection], key)
+
+	return nil
+}
+
+// refOf => ref of a stored value; derived from its content, so it changes whenever the value does
+func refOf(raw []byte) string {
+	sum := sha1.Sum(raw)
+	return hex.EncodeToString(sum[:8])
+}
+
+// Truncate => removes every value in a collection
+func (s *Store) Truncate(collection string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n := len(s.collections[collection])
+	delete(s.collections, collection)
+
+	return n, nil
+}
+
+// List => unmarshals up to limit values, ordered by key, into the slice pointed to by v
+// NOTE: afterKey is exclusive and startKey is inclusive, mirroring Orchestrate's list semantics
+func (s *Store) List(collection string, limit int, afterKey, startKey string, v interface{}) (db.Page, error) {
+	var page db.Page
+
+	s.mu.RLock()
+	keys := s.sortedKeys(collection)
+
+	// index of the first key on this page
+	first := sort.Search(len(keys), func(i int) bool {
+		if afterKey != "" {
+			return keys[i] > afterKey
+		}
+		return keys[i] >= startKey
+	})
+
+	last := len(keys)
+	if limit > 0 && first+limit < last {
+		last = first + limit
+		page.NextKey = keys[last-1]
+	}
+
+	raws := make([]json.RawMessage, 0, last-first)
+	for _, k := range keys[first:last] {
+		raws = append(raws, s.collections[collection][k])
+	}
+	s.mu.RUnlock()
+
+	// the preceding page holds the limit keys before this one
+	if limit > 0 && first > 0 {
+		page.HasPrev = true
+		if prevFirst := first - limit; prevFirst > 0 {
+			page.PrevKey = keys[prevFirst-1]
+		}
+	}
+
+	return page, decodeAll(raws, v)
+}
+
+// Search => filters, sorts, pages and projects a collection, then unmarshals the result into v
+func (s *Store) Search(collection string, q db.Query, v interface{}) error {
+	s.mu.RLock()
+	docs := make([]map[string]interface{}, 0, len(s.collections[collection]))
+	for _, k := range s.sortedKeys(collection) {
+		doc := make(map[string]interface{})
+		if err := json.Unmarshal(s.collections[collection][k], &doc); err != nil {
+			s.mu.RUnlock()
+			return err
+		}
+		docs = append(docs, doc)
+	}
+	s.mu.RUnlock()
+
+	// where
+	matches := make([]map[string]interface{}, 0, len(docs))
+	for _, doc := range docs {
+		ok, err := matchAll(doc, q.Where)
+		if err != nil {
+			return err
+		}
+		if ok {
+			matches = append(matches, doc)
+		}
+	}
+
+	// order
+	if len(q.Order) > 0 {
+		sort.Stable(byOrder{docs: matches, order: q.Order})
+	}
+
+	// offset + limit
+	if q.Offset > 0 {
+		if q.Offset >= len(matches) {
+			matches = matches[:0]
+		} else {
+			matches = matches[q.Offset:]
+		}
+	}
+	if q.Limit > 0 && len(matches) > q.Limit {
+		matches = matches[:q.Limit]
+	}
+
+	// pluck
+	raws := make([]json.RawMessage, len(matches))
+	for i, doc := range matches {
+		if len(q.Pluck) > 0 {
+			projected := make(map[string]interface{}, len(q.Pluck))
+			for _, key := range q.Pluck {
+				if val, ok := doc[key]; ok {
+					projected[key] = val
+				}
+			}
+			doc = projected
+		}
+
+		raw, err := json.Marshal(doc)
+		if err != nil {
+			return err
+		}
+		raws[i] = raw
+	}
+
+	return decodeAll(raws, v)
+}
+
+// NOTE: callers must hold at least a read lock
+func (s *Store) sortedKeys(collection string) []string {
+	keys := make([]string, 0, len(s.collections[collection]))
+	for k := range s.collections[collection] {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func decodeAll(raws []json.RawMessage, v interface{}) error {
+	raw, err := json.Marshal(raws)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, v)
+}
+
+// byOrder => sorts documents by one or more keys
+type byOrder struct {
+	docs  []map[string]interface{}
+	order []goar.OrderBy
+}
+
+func (b byOrder) Len() int      { return len(b.docs) }
+func (b byOrder) Swap(i, j int) { b.docs[i], b.docs[j] = b.docs[j], b.docs[i] }
+func (b byOrder) Less(i, j int) bool {
+	for _, o := range b.order {
+		c, _ := compare(b.docs[i][o.Key], b.docs[j][o.Key])
+		if c == 0 {
+			continue
+		}
+		if o.SortOrder == goar.DESC {
+			return c > 0
+		}
+		return c < 0
+	}
+	return false
+}
+
+// matchAll => evaluates where conditions left to right, honoring each condition's logical operator
+func matchAll(doc map[string]interface{}, conditions []goar.QueryCondition) (bool, error) {
+	result := true
+
+	for i, cond := range conditions {
+		ok, err := match(doc, cond)
+		if err != nil {
+			return false, err
+		}
+
+		switch {
+		case i == 0:
+			result = ok
+		case cond.LogicalOperator == goar.OR:
+			result = result || ok
+		default:
+			result = result && ok
+		}
+	}
+
+	return result, nil
+}
+
+func match(doc map[string]interface{}, cond goar.QueryCondition) (bool, error) {
+	val, exists := doc[cond.Key]
+
+	c, comparable := compare(val, cond.Value)
+
+	switch cond.RelationalOperator {
+	case goar.EQ:
+		return exists && comparable && c == 0, nil
+	case goar.NE:
+		return !exists || !comparable || c != 0, nil
+	case goar.LT:
+		return exists && comparable && c < 0, nil
+	case goar.LTE:
+		return exists && comparable && c <= 0, nil
+	case goar.GT:
+		return exists && comparable && c > 0, nil
+	case goar.GTE:
+		return exists && comparable && c >= 0, nil
+	default:
+		return false, fmt.Errorf("memory: unsupported relational operator %v", cond.RelationalOperator)
+	}
+}
+
+// compare => orders two JSON scalars; numbers compare numerically and everything else as case-insensitive strings
+func compare(a, b interface{}) (int, bool) {
+	if a == nil || b == nil {
+		return 0, a == nil && b == nil
+	}
+
+	if fa, ok := toFloat(a); ok {
+		if fb, ok := toFloat(b); ok {
+			switch {
+			case fa < fb:
+				return -1, true
+			case fa > fb:
+				return 1, true
+			default:
+				return 0, true
+			}
+		}
+	}
+
+	sa := strings.ToLower(fmt.Sprint(a))
+	sb := strings.ToLower(fmt.Sprint(b))
+	switch {
+	case sa < sb:
+		return -1, true
+	case sa > sb:
+		return 1, true
+	default:
+		return 0, true
+	}
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint:
+		return float64(n), true
+	case uint32:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
@@ -0,0 +1,179 @@
+package memory
+
+import (
+	"reflect"
+	"testing"
+
+	goar "github.com/obieq/goar"
+	"github.com/obieq/rva-devops-api/db"
+)
+
+type car struct {
+	ID    string `json:"id"`
+	Make  string `json:"make,omitempty"`
+	Model string `json:"model,omitempty"`
+	Year  int    `json:"year,omitempty"`
+}
+
+func ids(cars []car) []string {
+	ids := make([]string, len(cars))
+	for i, c := range cars {
+		ids[i] = c.ID
+	}
+	return ids
+}
+
+// fleet => a store holding cars a to e
+func fleet(t *testing.T) *Store {
+	s := NewStore()
+	for _, c := range []car{
+		{ID: "c", Make: "Ford", Model: "Focus", Year: 2012},
+		{ID: "a", Make: "audi", Model: "A4", Year: 2005},
+		{ID: "e", Make: "Tesla", Model: "S", Year: 2015},
+		{ID: "b", Make: "BMW", Model: "M3", Year: 2005},
+		{ID: "d", Make: "Ford", Model: "Fiesta", Year: 2009},
+	} {
+		if _, err := s.Put("cars", c.ID, c); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return s
+}
+
+func TestList(t *testing.T) {
+	s := fleet(t)
+
+	tests := []struct {
+		name               string
+		limit              int
+		afterKey, startKey string
+		want               []string
+		page               db.Page
+	}{
+		{"everything", 0, "", "", []string{"a", "b", "c", "d", "e"}, db.Page{}},
+		{"first page", 2, "", "", []string{"a", "b"}, db.Page{NextKey: "b"}},
+		{"second page", 2, "b", "", []string{"c", "d"}, db.Page{NextKey: "d", HasPrev: true}},
+		{"last page", 2, "d", "", []string{"e"}, db.Page{HasPrev: true, PrevKey: "b"}},
+		{"exact last page", 1, "d", "", []string{"e"}, db.Page{HasPrev: true, PrevKey: "c"}},
+		{"start key is inclusive", 2, "", "b", []string{"b", "c"}, db.Page{NextKey: "c", HasPrev: true}},
+		{"after the last key", 2, "e", "", []string{}, db.Page{HasPrev: true, PrevKey: "c"}},
+	}
+
+	for _, test := range tests {
+		var cars []car
+		page, err := s.List("cars", test.limit, test.afterKey, test.startKey, &cars)
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if got := ids(cars); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: listed %v, want %v", test.name, got, test.want)
+		}
+		if page != test.page {
+			t.Errorf("%s: page %+v, want %+v", test.name, page, test.page)
+		}
+	}
+}
+
+func TestSearch(t *testing.T) {
+	s := fleet(t)
+	where := func(logical goar.EnumLogicalOperators, key string, op goar.EnumRelationalOperators, value interface{}) goar.QueryCondition {
+		return goar.QueryCondition{LogicalOperator: logical, Key: key, RelationalOperator: op, Value: value}
+	}
+
+	tests := []struct {
+		name  string
+		query db.Query
+		want  []string
+	}{
+		{"everything, by key", db.Query{}, []string{"a", "b", "c", "d", "e"}},
+		{"strings compare case-insensitively", db.Query{Where: []goar.QueryCondition{
+			where(goar.AND, "make", goar.EQ, "ford"),
+		}}, []string{"c", "d"}},
+		{"numbers compare numerically", db.Query{Where: []goar.QueryCondition{
+			where(goar.AND, "year", goar.GTE, 2009),
+			where(goar.AND, "year", goar.LT, 2015),
+		}}, []string{"c", "d"}},
+		{"or", db.Query{Where: []goar.QueryCondition{
+			where(goar.AND, "year", goar.EQ, 2015),
+			where(goar.OR, "model", goar.EQ, "A4"),
+		}}, []string{"a", "e"}},
+		{"ne matches missing attributes", db.Query{Where: []goar.QueryCondition{
+			where(goar.AND, "color", goar.NE, "red"),
+		}}, []string{"a", "b", "c", "d", "e"}},
+		{"order by several keys", db.Query{Order: []goar.OrderBy{
+			{Key: "year", SortOrder: goar.DESC},
+			{Key: "make", SortOrder: goar.ASC},
+		}}, []string{"e", "c", "d", "a", "b"}},
+		{"offset and limit", db.Query{Order: []goar.OrderBy{{Key: "year", SortOrder: goar.ASC}}, Offset: 1, Limit: 2}, []string{"b", "d"}},
+		{"offset past the end", db.Query{Offset: 5}, []string{}},
+	}
+
+	for _, test := range tests {
+		var cars []car
+		if err := s.Search("cars", test.query, &cars); err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if got := ids(cars); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: found %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestSearchPluck(t *testing.T) {
+	s := fleet(t)
+
+	var cars []car
+	query := db.Query{Where: []goar.QueryCondition{{Key: "id", RelationalOperator: goar.EQ, Value: "c"}}, Pluck: []string{"id", "model"}}
+	if err := s.Search("cars", query, &cars); err != nil {
+		t.Fatal(err)
+	}
+	if want := []car{{ID: "c", Model: "Focus"}}; !reflect.DeepEqual(cars, want) {
+		t.Errorf("plucked %+v, want %+v", cars, want)
+	}
+}
+
+func TestConditionalWrites(t *testing.T) {
+	s := NewStore()
+
+	ref, err := s.PutIfAbsent("cars", "a", car{ID: "a", Year: 2005})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.PutIfAbsent("cars", "a", car{ID: "a", Year: 2006}); db.KindOf(err) != db.Conflict {
+		t.Errorf("PutIfAbsent on a taken key: %v, want a conflict", err)
+	}
+
+	updated, err := s.PutIfUnmodified("cars", "a", ref, car{ID: "a", Year: 2006})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated == ref {
+		t.Error("the ref did not change with the value")
+	}
+	if _, err := s.PutIfUnmodified("cars", "a", ref, car{ID: "a", Year: 2007}); db.KindOf(err) != db.PreconditionFailed {
+		t.Errorf("PutIfUnmodified with a stale ref: %v, want a failed precondition", err)
+	}
+	if _, err := s.PutIfUnmodified("cars", "b", ref, car{ID: "b"}); db.KindOf(err) != db.NotFound {
+		t.Errorf("PutIfUnmodified on a missing key: %v, want not found", err)
+	}
+
+	if err := s.DeleteIfUnmodified("cars", "a", ref); db.KindOf(err) != db.PreconditionFailed {
+		t.Errorf("DeleteIfUnmodified with a stale ref: %v, want a failed precondition", err)
+	}
+	var stored car
+	if current, err := s.Get("cars", "a", &stored); err != nil || current != updated || stored.Year != 2006 {
+		t.Fatalf("after the failed writes: ref %q, %+v, %v; want the update kept", current, stored, err)
+	}
+
+	if err := s.DeleteIfUnmodified("cars", "a", updated); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Get("cars", "a", &stored); db.KindOf(err) != db.NotFound {
+		t.Errorf("Get after the delete: %v, want not found", err)
+	}
+	if err := s.DeleteIfUnmodified("cars", "a", updated); db.KindOf(err) != db.NotFound {
+		t.Errorf("DeleteIfUnmodified on a missing key: %v, want not found", err)
+	}
+}
@@ -0,0 +1,28 @@
+package db
+
+import (
+	"strings"
+	"time"
+
+	"github.com/obieq/rva-devops-api/metrics"
+)
+
+var (
+	operations = metrics.NewCounterVec("backend_operations_total",
+		"Storage backend operations by operation, collection and outcome (ok or the db.ErrorKind of the failure).",
+		"operation", "collection", "outcome")
+	operationDuration = metrics.NewHistogramVec("backend_operation_duration_seconds",
+		"Latency of storage backend operations by operation and collection.",
+		nil, "operation", "collection")
+)
+
+// observe => records an operation of the goar persister (Find, Save, Delete, Search or List) that began at start
+func observe(operation, collection string, start time.Time, err error) {
+	outcome := "ok"
+	if err != nil {
+		outcome = strings.Replace(KindOf(err).String(), " ", "_", -1)
+	}
+
+	operations.Inc(operation, collection, outcome)
+	operationDuration.Observe(time.Since(start).Seconds(), operation, collection)
+}
@@ -0,0 +1,38 @@
+package orchestrate
+
+import (
+	"net"
+	"net/url"
+
+	"github.com/obieq/rva-devops-api/db"
+	c "github.com/orchestrate-io/gorc"
+)
+
+// classify => translates a gorc error into the db error taxonomy by its HTTP status code
+func classify(err error) error {
+	switch t := err.(type) {
+	case nil:
+		return nil
+	case *c.OrchestrateError:
+		return db.NewError(kindOf(t.StatusCode), err)
+	case *url.Error, net.Error:
+		return db.NewError(db.Unavailable, err)
+	}
+	return db.NewError(db.Unknown, err)
+}
+
+func kindOf(statusCode int) db.ErrorKind {
+	switch {
+	case statusCode == 404:
+		return db.NotFound
+	case statusCode == 409:
+		return db.Conflict
+	case statusCode == 412:
+		return db.PreconditionFailed
+	case statusCode == 401 || statusCode == 403:
+		return db.Unauthorized
+	case statusCode == 429 || statusCode >= 500:
+		return db.Unavailable
+	}
+	return db.Unknown
+}
@@ -0,0 +1,36 @@
+package orchestrate
+
+import (
+	"time"
+
+	"github.com/obieq/rva-devops-api/db"
+)
+
+// PutEvent => appends an event, timestamped by Orchestrate, to the item's timeline
+func (b *Backend) PutEvent(collection, key, kind string, v interface{}) error {
+	return classify(b.client.PutEvent(collection, key, kind, v))
+}
+
+// GetEventsInRange => events between start and end, which Orchestrate resolves to milliseconds
+func (b *Backend) GetEventsInRange(collection, key, kind string, start, end time.Time, limit int) ([]db.Event, error) {
+	response, err := b.client.GetEventsInRangeWithLimit(collection, key, kind, millis(start), millis(end), int64(limit))
+	if err != nil {
+		return nil, classify(err)
+	}
+
+	events := make([]db.Event, len(response.Results))
+	for i, result := range response.Results {
+		ms := int64(result.Timestamp)
+		events[i] = db.Event{
+			Timestamp: time.Unix(ms/1000, ms%1000*int64(time.Millisecond)).UTC(),
+			Ordinal:   result.Ordinal,
+			RawValue:  result.RawValue,
+		}
+	}
+
+	return events, nil
+}
+
+func millis(t time.Time) int64 {
+	return t.UnixNano() / int64(time.Millisecond)
+}
@@ -0,0 +1,289 @@
+package orchestrate
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strings"
+
+	goar "github.com/obieq/goar"
+	"github.com/obieq/rva-devops-api/db"
+	c "github.com/orchestrate-io/gorc"
+)
+
+const (
+	defaultListLimit   = 10 // per Orchestrate's documentation: 10 default, 100 max
+	maxListLimit       = 100
+	defaultSearchLimit = 100
+)
+
+func init() {
+	db.Register("orchestrate", func(opts map[string]string) (db.Backend, error) {
+		return New(opts["api_key"])
+	})
+}
+
+// Backend => db.Backend backed by Orchestrate's key/value and search APIs
+type Backend struct {
+	client *c.Client
+}
+
+// New => creates an Orchestrate backend for the given API key
+func New(apiKey string) (*Backend, error) {
+	if apiKey == "" {
+		return nil, errors.New("orchestrate: api key is required")
+	}
+
+	return &Backend{client: c.NewClient(apiKey)}, nil
+}
+
+// Client => underlying gorc client, for features outside of db.Backend
+func (b *Backend) Client() *c.Client {
+	return b.client
+}
+
+// Close => no-op; gorc holds no connections beyond its shared transport
+func (b *Backend) Close() error {
+	return nil
+}
+
+// Ping => checks that Orchestrate is reachable and accepts the api key; satisfies db.Pinger
+func (b *Backend) Ping() error {
+	return classify(b.client.Ping())
+}
+
+func (b *Backend) Get(collection, key string, v interface{}) (string, error) {
+	result, err := b.client.Get(collection, key)
+	if err != nil {
+		return "", classify(err)
+	}
+
+	return result.Path.Ref, result.Value(v)
+}
+
+func (b *Backend) Put(collection, key string, v interface{}) (string, error) {
+	return written(b.client.Put(collection, key, v))
+}
+
+// PutIfAbsent => Orchestrate answers a taken key with 412 (If-None-Match failed), reported here as a conflict
+func (b *Backend) PutIfAbsent(collection, key string, v interface{}) (string, error) {
+	r, err := written(b.client.PutIfAbsent(collection, key, v))
+	if db.KindOf(err) == db.PreconditionFailed {
+		err.(*db.Error).Kind = db.Conflict
+	}
+	return r, err
+}
+
+func (b *Backend) PutIfUnmodified(collection, key, ref string, v interface{}) (string, error) {
+	return written(b.client.PutIfUnmodified(&c.Path{Collection: collection, Key: key, Ref: ref}, v))
+}
+
+func (b *Backend) Delete(collection, key string) error {
+	return classify(b.client.Purge(collection, key))
+}
+
+// DeleteIfUnmodified => deletes without purging, so the value's history is kept (Orchestrate does not support a conditional purge)
+func (b *Backend) DeleteIfUnmodified(collection, key, ref string) error {
+	return classify(b.client.DeleteIfUnmodified(&c.Path{Collection: collection, Key: key, Ref: ref}))
+}
+
+// written => the ref of a written value
+func written(path *c.Path, err error) (string, error) {
+	if err != nil {
+		return "", classify(err)
+	}
+	return path.Ref, nil
+}
+
+// Truncate => deletes the collection; Orchestrate does not report how many items were removed
+func (b *Backend) Truncate(collection string) (int, error) {
+	return -1, classify(b.client.DeleteCollection(collection))
+}
+
+// List => pages forward only; Orchestrate has no way to list the keys before a given key
+func (b *Backend) List(collection string, limit int, afterKey, startKey string, v interface{}) (page db.Page, err error) {
+	var response *c.KVResults
+
+	if limit <= 0 {
+		limit = defaultListLimit
+	} else if limit > maxListLimit {
+		return page, fmt.Errorf("orchestrate: limit must not exceed %d", maxListLimit)
+	}
+
+	switch {
+	case afterKey != "":
+		response, err = b.client.ListAfter(collection, afterKey, limit)
+	case startKey != "":
+		response, err = b.client.ListStart(collection, startKey, limit)
+	default:
+		response, err = b.client.List(collection, limit)
+	}
+
+	if err != nil {
+		return page, classify(err)
+	}
+
+	// surface the next page's cursor, e.g. "/v0/automobiles?limit=10&afterKey=abc"
+	if response.HasNext() {
+		if next, err := url.Parse(response.Next); err == nil {
+			page.NextKey = next.Query().Get("afterKey")
+		}
+	}
+
+	raws := make([]json.RawMessage, len(response.Results))
+	for i, result := range response.Results {
+		raws[i] = result.RawValue
+	}
+
+	return page, mapResults(raws, v)
+}
+
+func (b *Backend) Search(collection string, q db.Query, v interface{}) (err error) {
+	var query string
+	var response *c.SearchResults
+
+	if query, err = luceneQuery(q.Where); err != nil {
+		return err
+	}
+	sort := sortBy(q.Order)
+
+	limit := q.Limit
+	if limit <= 0 {
+		limit = defaultSearchLimit
+	}
+
+	if sort == "" {
+		response, err = b.client.Search(collection, query, limit, q.Offset)
+	} else {
+		response, err = b.client.SearchSorted(collection, query, sort, limit, q.Offset)
+	}
+
+	if err != nil {
+		return classify(err)
+	}
+
+	raws := make([]json.RawMessage, len(response.Results))
+	for i, result := range response.Results {
+		raws[i] = result.RawValue
+	}
+
+	return mapResults(raws, v)
+}
+
+// mapResults => unmarshals raw Orchestrate values into the slice pointed to by models
+func mapResults(raws []json.RawMessage, models interface{}) error {
+	modelsv := reflect.ValueOf(models)
+	if modelsv.Kind() != reflect.Ptr || modelsv.Elem().Kind() != reflect.Slice {
+		return errors.New("orchestrate: models argument must be a slice address")
+	}
+	slicev := modelsv.Elem()
+	elemt := slicev.Type().Elem()
+
+	for _, raw := range raws {
+		elemp := reflect.New(elemt)
+		if err := json.Unmarshal(raw, elemp.Interface()); err != nil {
+			return err
+		}
+
+		slicev = reflect.Append(slicev, elemp.Elem())
+	}
+
+	modelsv.Elem().Set(slicev)
+
+	return nil
+}
+
+// luceneQuery => translates where conditions into Lucene query syntax
+// NOTE: an empty where clause matches every item in the collection; string values are quoted (terms) or
+// escaped (range bounds), so they are always matched literally
+func luceneQuery(conditions []goar.QueryCondition) (string, error) {
+	var stmt string
+
+	if len(conditions) == 0 {
+		return "*", nil
+	}
+
+	for i, where := range conditions {
+		var clause string
+		term, bound := luceneTerm(where.Value), luceneBound(where.Value)
+
+		switch where.RelationalOperator {
+		case goar.EQ:
+			clause = where.Key + ":" + term
+		case goar.NE:
+			clause = "NOT " + where.Key + ":" + term
+		case goar.LT:
+			clause = where.Key + ":{* TO " + bound + "}"
+		case goar.LTE:
+			clause = where.Key + ":[* TO " + bound + "]"
+		case goar.GT:
+			clause = where.Key + ":{" + bound + " TO *}"
+		case goar.GTE:
+			clause = where.Key + ":[" + bound + " TO *]"
+		default:
+			return "", fmt.Errorf("orchestrate: invalid comparison operator: %v", where.RelationalOperator)
+		}
+
+		switch {
+		case i == 0:
+			stmt = clause
+		case where.LogicalOperator == goar.OR:
+			stmt = stmt + " OR " + clause
+		default:
+			stmt = stmt + " AND " + clause
+		}
+	}
+
+	return stmt, nil
+}
+
+// luceneSpecial => characters with a meaning in Lucene query syntax; whitespace separates terms
+const luceneSpecial = `+-&|!(){}[]^"~*?:\\/ ` + "\t\n"
+
+// luceneTerm => value as a term: strings become a quoted phrase, other values (numbers, booleans) are used as is
+func luceneTerm(value interface{}) string {
+	s, ok := value.(string)
+	if !ok {
+		return fmt.Sprintf("%v", value)
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// luceneBound => value as a range bound, with every special character of a string backslash-escaped
+func luceneBound(value interface{}) string {
+	s, ok := value.(string)
+	if !ok {
+		return fmt.Sprintf("%v", value)
+	}
+
+	escaped := make([]rune, 0, len(s))
+	for _, r := range s {
+		if strings.ContainsRune(luceneSpecial, r) {
+			escaped = append(escaped, '\\')
+		}
+		escaped = append(escaped, r)
+	}
+	return string(escaped)
+}
+
+// sortBy => translates order bys into Orchestrate's "value.field:asc,value.other:desc" syntax
+func sortBy(orderBys []goar.OrderBy) (sort string) {
+	for i, orderBy := range orderBys {
+		if i > 0 {
+			sort += ","
+		}
+
+		sort += "value." + orderBy.Key + ":"
+
+		switch orderBy.SortOrder {
+		case goar.DESC:
+			sort += "desc"
+		default:
+			sort += "asc"
+		}
+	}
+
+	return sort
+}
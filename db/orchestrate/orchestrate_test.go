@@ -0,0 +1,74 @@
+package orchestrate
+
+import (
+	"testing"
+
+	goar "github.com/obieq/goar"
+)
+
+func TestLuceneQuery(t *testing.T) {
+	where := func(key string, op goar.EnumRelationalOperators, value interface{}) goar.QueryCondition {
+		return goar.QueryCondition{LogicalOperator: goar.AND, Key: key, RelationalOperator: op, Value: value}
+	}
+
+	tests := []struct {
+		name       string
+		conditions []goar.QueryCondition
+		want       string
+	}{
+		{"everything", nil, "*"},
+		{"term", []goar.QueryCondition{where("make", goar.EQ, "Ford")}, `make:"Ford"`},
+		{"negated term", []goar.QueryCondition{where("make", goar.NE, "Ford")}, `NOT make:"Ford"`},
+		{"number", []goar.QueryCondition{where("year", goar.EQ, 2000)}, "year:2000"},
+		{"boolean", []goar.QueryCondition{where("insured", goar.EQ, true)}, "insured:true"},
+		{"ranges", []goar.QueryCondition{
+			where("year", goar.GTE, 2000),
+			where("year", goar.LT, 2010),
+		}, "year:[2000 TO *] AND year:{* TO 2010}"},
+		{"exclusive and inclusive bounds", []goar.QueryCondition{
+			where("year", goar.GT, 2000),
+			where("year", goar.LTE, 2010),
+		}, "year:{2000 TO *} AND year:[* TO 2010]"},
+		{"or", []goar.QueryCondition{
+			where("make", goar.EQ, "Ford"),
+			{LogicalOperator: goar.OR, Key: "make", RelationalOperator: goar.EQ, Value: "Audi"},
+		}, `make:"Ford" OR make:"Audi"`},
+
+		// values are matched literally, whatever they hold
+		{"quoted term", []goar.QueryCondition{where("make", goar.EQ, `x" OR *:*`)}, `make:"x\" OR *:*"`},
+		{"backslash in term", []goar.QueryCondition{where("make", goar.EQ, `a\`)}, `make:"a\\"`},
+		{"wildcard term", []goar.QueryCondition{where("make", goar.EQ, "F*")}, `make:"F*"`},
+		{"escaped bound", []goar.QueryCondition{where("created_at", goar.GT, "2015-06-01T00:00:00Z")},
+			`created_at:{2015\-06\-01T00\:00\:00Z TO *}`},
+		{"bound breaking out of the range", []goar.QueryCondition{where("make", goar.LT, "a} OR make:[* TO *")},
+			`make:{* TO a\}\ OR\ make\:\[\*\ TO\ \*}`},
+	}
+
+	for _, test := range tests {
+		got, err := luceneQuery(test.conditions)
+		if err != nil {
+			t.Errorf("%s: luceneQuery error: %v", test.name, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("%s: luceneQuery = %s, want %s", test.name, got, test.want)
+		}
+	}
+}
+
+func TestLuceneQueryInvalidOperator(t *testing.T) {
+	conditions := []goar.QueryCondition{{Key: "year", RelationalOperator: goar.EnumRelationalOperators(99), Value: 1}}
+	if _, err := luceneQuery(conditions); err == nil {
+		t.Error("luceneQuery accepted an unknown operator")
+	}
+}
+
+func TestSortBy(t *testing.T) {
+	orderBys := []goar.OrderBy{{Key: "year", SortOrder: goar.DESC}, {Key: "make", SortOrder: goar.ASC}}
+	if got, want := sortBy(orderBys), "value.year:desc,value.make:asc"; got != want {
+		t.Errorf("sortBy = %s, want %s", got, want)
+	}
+	if got := sortBy(nil); got != "" {
+		t.Errorf("sortBy(nil) = %q, want none", got)
+	}
+}
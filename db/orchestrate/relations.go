@@ -0,0 +1,29 @@
+package orchestrate
+
+import "github.com/obieq/rva-devops-api/db"
+
+// PutRelation => creates a graph relation from source to sink
+func (b *Backend) PutRelation(sourceCollection, sourceKey, kind, sinkCollection, sinkKey string) error {
+	return classify(b.client.PutRelation(sourceCollection, sourceKey, kind, sinkCollection, sinkKey))
+}
+
+// DeleteRelation => purges the graph relation from source to sink
+func (b *Backend) DeleteRelation(sourceCollection, sourceKey, kind, sinkCollection, sinkKey string) error {
+	return classify(b.client.DeleteRelation(sourceCollection, sourceKey, kind, sinkCollection, sinkKey))
+}
+
+// GetRelations => follows a single hop of the graph
+// NOTE: gorc does not expose a limit, so Orchestrate's default page of relations applies
+func (b *Backend) GetRelations(collection, key, kind string) ([]db.Related, error) {
+	response, err := b.client.GetRelations(collection, key, []string{kind})
+	if err != nil {
+		return nil, classify(err)
+	}
+
+	results := make([]db.Related, len(response.Results))
+	for i, result := range response.Results {
+		results[i] = db.Related{Collection: result.Path.Collection, Key: result.Path.Key, RawValue: result.RawValue}
+	}
+
+	return results, nil
+}
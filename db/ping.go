@@ -0,0 +1,19 @@
+package db
+
+// Pinger => optional Backend capability to check that the database behind it is reachable
+type Pinger interface {
+	Ping() error
+}
+
+// Ping => checks the current backend; backends that cannot be pinged are assumed reachable
+func Ping() error {
+	backend := Current()
+	if backend == nil {
+		return ErrNoBackend
+	}
+
+	if pinger, ok := backend.(Pinger); ok {
+		return pinger.Ping()
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package db
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// ErrRelationsUnsupported => returned when the selected backend cannot store graph relations
+var ErrRelationsUnsupported = errors.New("db: storage backend does not support relations")
+
+// Related => an item reached by following a relation
+type Related struct {
+	Collection string
+	Key        string
+	RawValue   json.RawMessage
+}
+
+// Value => unmarshals the related item into v
+func (r Related) Value(v interface{}) error {
+	return json.Unmarshal(r.RawValue, v)
+}
+
+// Relater => optional Backend capability for directed, named relations between items
+// NOTE: relations are one-way; callers that need to traverse both directions store an inverse relation
+type Relater interface {
+	PutRelation(sourceCollection, sourceKey, kind, sinkCollection, sinkKey string) error
+	DeleteRelation(sourceCollection, sourceKey, kind, sinkCollection, sinkKey string) error
+	// GetRelations => items reached from collection/key via kind; items that no longer exist are skipped
+	GetRelations(collection, key, kind string) ([]Related, error)
+}
+
+// Relations => the current backend's Relater
+func Relations() (Relater, error) {
+	backend := Current()
+	if backend == nil {
+		return nil, ErrNoBackend
+	}
+
+	relater, ok := backend.(Relater)
+	if !ok {
+		return nil, ErrRelationsUnsupported
+	}
+
+	return relater, nil
+}
@@ -0,0 +1,172 @@
+package health
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/martini-contrib/render"
+	"github.com/obieq/rva-devops-api/logger"
+)
+
+// Statuses
+const (
+	UP   string = "up"
+	DOWN string = "down"
+)
+
+// CHECK_TIMEOUT => how long readiness waits for a dependency before reporting it down; results are reused
+// for as long, so frequent probes do not hammer the dependencies
+const CHECK_TIMEOUT = 3 * time.Second
+
+var (
+	errDraining = errors.New("the server is shutting down")
+	errTimeout  = errors.New("the check timed out")
+	errFailed   = errors.New("the dependency is unavailable")
+)
+
+// Check => result of probing one dependency
+type Check struct {
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// Report => overall status and the check of each dependency, by name
+type Report struct {
+	Status string           `json:"status"`
+	Checks map[string]Check `json:"checks"`
+}
+
+var (
+	draining int32
+
+	checksMu sync.RWMutex
+	checks   = make(map[string]func() error)
+
+	cachedMu sync.Mutex
+	cached   map[string]Check
+	ranAt    time.Time
+)
+
+// Register => adds a dependency that must be reachable for the server to be ready, e.g. db.Ping
+// NOTE: checksMu is released before cachedMu is taken; latest holds cachedMu while run takes checksMu
+func Register(name string, check func() error) {
+	checksMu.Lock()
+	checks[name] = check
+	checksMu.Unlock()
+
+	cachedMu.Lock()
+	cached = nil
+	cachedMu.Unlock()
+}
+
+// SetDraining => while true, readiness fails so load balancers stop routing new requests here
+func SetDraining(on bool) {
+	var v int32
+	if on {
+		v = 1
+	}
+	atomic.StoreInt32(&draining, v)
+}
+
+// Draining => whether the server is shutting down
+func Draining() bool {
+	return atomic.LoadInt32(&draining) == 1
+}
+
+// Live => GET /healthz; 200 as long as the process can serve requests, whatever the state of its dependencies
+func Live(r render.Render) {
+	r.JSON(200, Report{
+		Status: UP,
+		Checks: map[string]Check{"process": {Status: UP}},
+	})
+}
+
+// Ready => GET /readyz; runs every registered check concurrently and responds 200 if all are up, 503 otherwise
+// NOTE: the "process" check is down while the server is draining (see SetDraining), which is never cached.
+// Failures are reported without their details, which are logged instead
+func Ready(r render.Render) {
+	report := Report{Status: UP, Checks: latest()}
+
+	process := Check{Status: UP}
+	if Draining() {
+		process.Status, process.Error = DOWN, errDraining.Error()
+	}
+	report.Checks["process"] = process
+
+	status := 200
+	for _, check := range report.Checks {
+		if check.Status != UP {
+			report.Status, status = DOWN, 503
+		}
+	}
+	r.JSON(status, report)
+}
+
+// latest => a copy of the results of the last run, running the checks again once those are CHECK_TIMEOUT old
+// NOTE: concurrent callers wait for a single run rather than each probing the dependencies
+func latest() map[string]Check {
+	cachedMu.Lock()
+	defer cachedMu.Unlock()
+
+	if cached == nil || time.Since(ranAt) >= CHECK_TIMEOUT {
+		cached, ranAt = run(), time.Now()
+	}
+
+	report := make(map[string]Check, len(cached)+1)
+	for name, check := range cached {
+		report[name] = check
+	}
+	return report
+}
+
+// run => the result of every registered check; checks that outlast CHECK_TIMEOUT are reported down
+func run() map[string]Check {
+	checksMu.RLock()
+	defer checksMu.RUnlock()
+
+	type result struct {
+		name  string
+		check Check
+	}
+	results := make(chan result, len(checks))
+	for name, check := range checks {
+		go func(name string, check func() error) {
+			results <- result{name, probe(name, check)}
+		}(name, check)
+	}
+
+	report := make(map[string]Check, len(checks))
+	timeout := time.After(CHECK_TIMEOUT)
+	for len(report) < len(checks) {
+		select {
+		case res := <-results:
+			report[res.name] = res.check
+		case <-timeout:
+			for name := range checks {
+				if _, ok := report[name]; !ok {
+					report[name] = Check{Status: DOWN, LatencyMs: ms(CHECK_TIMEOUT), Error: errTimeout.Error()}
+				}
+			}
+		}
+	}
+	return report
+}
+
+func probe(name string, check func() error) Check {
+	start := time.Now()
+	err := check()
+
+	result := Check{Status: UP, LatencyMs: ms(time.Since(start))}
+	if err != nil {
+		logger.Error("health:", name, "=>", err)
+		result.Status, result.Error = DOWN, errFailed.Error()
+	}
+	return result
+}
+
+func ms(d time.Duration) float64 {
+	return float64(d.Nanoseconds()) / 1e6
+}
@@ -0,0 +1,117 @@
+package health
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-martini/martini"
+	"github.com/martini-contrib/render"
+)
+
+// reset => no checks, no cached results and not draining
+func reset() {
+	checksMu.Lock()
+	checks = make(map[string]func() error)
+	checksMu.Unlock()
+
+	cachedMu.Lock()
+	cached = nil
+	cachedMu.Unlock()
+
+	SetDraining(false)
+}
+
+func ready(t *testing.T) (int, Report) {
+	m := martini.New()
+	m.Use(render.Renderer())
+	m.Action(Ready)
+
+	req, _ := http.NewRequest("GET", "/readyz", nil)
+	res := httptest.NewRecorder()
+	m.ServeHTTP(res, req)
+
+	var report Report
+	if err := json.Unmarshal(res.Body.Bytes(), &report); err != nil {
+		t.Fatalf("decoding %s: %v", res.Body.String(), err)
+	}
+	return res.Code, report
+}
+
+func TestReady(t *testing.T) {
+	reset()
+	defer reset()
+
+	Register("db", func() error { return nil })
+	if status, report := ready(t); status != 200 || report.Status != UP || report.Checks["db"].Status != UP || report.Checks["process"].Status != UP {
+		t.Errorf("ready: %d %+v, want 200 with db and process up", status, report)
+	}
+
+	// registering clears the cached results, so the new check runs on the next probe
+	Register("db", func() error { return errors.New("dial tcp: connection refused") })
+	status, report := ready(t)
+	if status != 503 || report.Status != DOWN || report.Checks["db"].Status != DOWN {
+		t.Errorf("failing check: %d %+v, want 503 with db down", status, report)
+	}
+	if report.Checks["db"].Error != errFailed.Error() {
+		t.Errorf("failing check reported %q, want the generic %q", report.Checks["db"].Error, errFailed.Error())
+	}
+}
+
+func TestReadyDraining(t *testing.T) {
+	reset()
+	defer reset()
+
+	Register("db", func() error { return nil })
+	ready(t)
+
+	SetDraining(true)
+	status, report := ready(t)
+	if status != 503 || report.Status != DOWN {
+		t.Errorf("draining: status %d %q, want 503 down", status, report.Status)
+	}
+	if process := report.Checks["process"]; process.Status != DOWN || process.Error != errDraining.Error() {
+		t.Errorf("draining: process %+v, want down with %q", process, errDraining.Error())
+	}
+	if report.Checks["db"].Status != UP {
+		t.Errorf("draining: db %+v, want the cached up result", report.Checks["db"])
+	}
+
+	SetDraining(false)
+	if status, _ := ready(t); status != 200 {
+		t.Errorf("after draining: status %d, want 200", status)
+	}
+}
+
+// TestRegisterDuringRun => Register while latest runs the checks must not deadlock
+// NOTE: not deferring reset, which would block on the deadlocked Register and hang the run instead of failing it
+func TestRegisterDuringRun(t *testing.T) {
+	reset()
+
+	// as latest does, hold cachedMu while Register is clearing the cache, then run the checks
+	cachedMu.Lock()
+	registered := make(chan struct{})
+	go func() {
+		defer close(registered)
+		Register("db", func() error { return nil })
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	ran := make(chan struct{})
+	go func() {
+		defer close(ran)
+		cached, ranAt = run(), time.Now()
+		cachedMu.Unlock()
+	}()
+
+	select {
+	case <-ran:
+	case <-time.After(time.Second):
+		t.Fatal("run waited on Register, which waits on latest")
+	}
+	<-registered
+	reset()
+}
@@ -0,0 +1,116 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Level => logging severity
+type Level int32
+
+const (
+	DEBUG Level = iota
+	INFO
+	WARN
+	ERROR
+)
+
+var levelNames = []string{"debug", "info", "warn", "error"}
+
+var level = int32(INFO)
+
+var (
+	recordMu  sync.Mutex
+	recordOut io.Writer = os.Stdout
+)
+
+// ParseLevel => converts "debug", "info", "warn" or "error" into a Level
+func ParseLevel(name string) (Level, error) {
+	for i, n := range levelNames {
+		if strings.EqualFold(n, name) {
+			return Level(i), nil
+		}
+	}
+	return INFO, fmt.Errorf("logger: unknown level %q", name)
+}
+
+func (l Level) String() string {
+	if l < DEBUG || l > ERROR {
+		return fmt.Sprintf("level(%d)", l)
+	}
+	return levelNames[l]
+}
+
+// SetLevel => messages below this level are discarded
+func SetLevel(l Level) {
+	atomic.StoreInt32(&level, int32(l))
+}
+
+// Enabled => true if messages at l are written
+func Enabled(l Level) bool {
+	return int32(l) >= atomic.LoadInt32(&level)
+}
+
+func Debug(v ...interface{}) { output(DEBUG, v...) }
+func Info(v ...interface{})  { output(INFO, v...) }
+func Warn(v ...interface{})  { output(WARN, v...) }
+func Error(v ...interface{}) { output(ERROR, v...) }
+
+func output(l Level, v ...interface{}) {
+	if Enabled(l) {
+		log.Output(3, "["+strings.ToUpper(l.String())+"] "+fmt.Sprintln(v...))
+	}
+}
+
+// Fields => structured data of a record, e.g. {"status": 200}
+type Fields map[string]interface{}
+
+// SetRecordOutput => destination of Record's JSON lines; stdout by default
+func SetRecordOutput(w io.Writer) {
+	recordMu.Lock()
+	recordOut = w
+	recordMu.Unlock()
+}
+
+// AUDIT_LEVEL => "level" of Audit's records
+const AUDIT_LEVEL string = "audit"
+
+// Record => writes fields as a single JSON line, adding "time", "level" and "msg"
+func Record(l Level, msg string, fields Fields) {
+	if Enabled(l) {
+		record(l.String(), msg, fields)
+	}
+}
+
+// Audit => like Record, but always written whatever the level set, with "level" set to AUDIT_LEVEL
+// NOTE: for security events (e.g. denied requests), which must not be lost to a quieter log level
+func Audit(msg string, fields Fields) {
+	record(AUDIT_LEVEL, msg, fields)
+}
+
+func record(level, msg string, fields Fields) {
+	line := make(Fields, len(fields)+3)
+	for k, v := range fields {
+		line[k] = v
+	}
+	line["time"] = time.Now().UTC().Format(time.RFC3339Nano)
+	line["level"] = level
+	line["msg"] = msg
+
+	raw, err := json.Marshal(line)
+	if err != nil {
+		Error("logger: unable to encode record:", err)
+		return
+	}
+
+	recordMu.Lock()
+	recordOut.Write(append(raw, '\n'))
+	recordMu.Unlock()
+}
@@ -0,0 +1,242 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// CONTENT_TYPE => media type of the Prometheus text exposition format
+const CONTENT_TYPE string = "text/plain; version=0.0.4; charset=utf-8"
+
+// DEFAULT_BUCKETS => upper bounds, in seconds, of latency histograms; the Prometheus client libraries' defaults
+var DEFAULT_BUCKETS = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// collector => a metric family that can write itself in the text format
+type collector interface {
+	name() string
+	write(w *bufio.Writer)
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   []collector
+)
+
+func register(c collector) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	for _, registered := range registry {
+		if registered.name() == c.name() {
+			panic("metrics: " + c.name() + " is already registered")
+		}
+	}
+	registry = append(registry, c)
+}
+
+// Handler => GET /metrics; every registered metric in the Prometheus text exposition format
+func Handler(res http.ResponseWriter, req *http.Request) {
+	res.Header().Set("Content-Type", CONTENT_TYPE)
+	Write(res)
+}
+
+// Write => writes every registered metric, in registration order, in the Prometheus text exposition format
+func Write(w io.Writer) error {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	buf := bufio.NewWriter(w)
+	for _, c := range registry {
+		c.write(buf)
+	}
+	return buf.Flush()
+}
+
+// family => what counters and histograms share: name, help and label names
+type family struct {
+	metricName string
+	help       string
+	labels     []string
+}
+
+func (f family) name() string {
+	return f.metricName
+}
+
+func (f family) header(w *bufio.Writer, kind string) {
+	fmt.Fprintf(w, "# HELP %s %s\n", f.metricName, strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(f.help))
+	fmt.Fprintf(w, "# TYPE %s %s\n", f.metricName, kind)
+}
+
+// key => identifies a series by its label values
+func (f family) key(values []string) string {
+	if len(values) != len(f.labels) {
+		panic(fmt.Sprintf("metrics: %s wants %d label values, got %d", f.metricName, len(f.labels), len(values)))
+	}
+	return strings.Join(values, "\xff")
+}
+
+// labelPairs => `a="x",b="y"`, followed by extra (e.g. `le="0.5"`) if given
+func (f family) labelPairs(values []string, extra string) string {
+	pairs := make([]string, 0, len(values)+1)
+	for i, value := range values {
+		pairs = append(pairs, f.labels[i]+`="`+escape(value)+`"`)
+	}
+	if extra != "" {
+		pairs = append(pairs, extra)
+	}
+	if len(pairs) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// CounterVec => counters partitioned by label values, e.g. requests by method and status
+type CounterVec struct {
+	family
+
+	mu     sync.Mutex
+	series map[string]*counter
+}
+
+type counter struct {
+	values []string
+	value  float64
+}
+
+// NewCounterVec => registers a counter family named name (e.g. "http_requests_total") with the given label names
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	v := &CounterVec{family: family{name, help, labels}, series: make(map[string]*counter)}
+	register(v)
+	return v
+}
+
+// Inc => adds one to the counter with the given label values
+func (v *CounterVec) Inc(values ...string) {
+	v.Add(1, values...)
+}
+
+// Add => adds delta, which must not be negative, to the counter with the given label values
+func (v *CounterVec) Add(delta float64, values ...string) {
+	key := v.key(values)
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	c, ok := v.series[key]
+	if !ok {
+		c = &counter{values: append([]string(nil), values...)}
+		v.series[key] = c
+	}
+	c.value += delta
+}
+
+func (v *CounterVec) write(w *bufio.Writer) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	keys := make([]string, 0, len(v.series))
+	for key := range v.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	v.header(w, "counter")
+	for _, key := range keys {
+		c := v.series[key]
+		fmt.Fprintf(w, "%s%s %s\n", v.metricName, v.labelPairs(c.values, ""), format(c.value))
+	}
+}
+
+// HistogramVec => histograms partitioned by label values, e.g. request latencies by route
+type HistogramVec struct {
+	family
+	buckets []float64
+
+	mu     sync.Mutex
+	series map[string]*histogram
+}
+
+type histogram struct {
+	values []string
+	counts []uint64 // observations per bucket, not cumulative
+	sum    float64
+	count  uint64
+}
+
+// NewHistogramVec => registers a histogram family with the given bucket upper bounds (DEFAULT_BUCKETS if nil)
+// and label names
+func NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	if buckets == nil {
+		buckets = DEFAULT_BUCKETS
+	}
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+
+	v := &HistogramVec{family: family{name, help, labels}, buckets: buckets, series: make(map[string]*histogram)}
+	register(v)
+	return v
+}
+
+// Observe => records value in the histogram with the given label values
+func (v *HistogramVec) Observe(value float64, values ...string) {
+	key := v.key(values)
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	h, ok := v.series[key]
+	if !ok {
+		h = &histogram{values: append([]string(nil), values...), counts: make([]uint64, len(v.buckets))}
+		v.series[key] = h
+	}
+
+	if i := sort.SearchFloat64s(v.buckets, value); i < len(v.buckets) {
+		h.counts[i]++
+	}
+	h.sum += value
+	h.count++
+}
+
+func (v *HistogramVec) write(w *bufio.Writer) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	keys := make([]string, 0, len(v.series))
+	for key := range v.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	v.header(w, "histogram")
+	for _, key := range keys {
+		h := v.series[key]
+
+		var cumulative uint64
+		for i, bound := range v.buckets {
+			cumulative += h.counts[i]
+			fmt.Fprintf(w, "%s_bucket%s %d\n", v.metricName, v.labelPairs(h.values, `le="`+format(bound)+`"`), cumulative)
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", v.metricName, v.labelPairs(h.values, `le="+Inf"`), h.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", v.metricName, v.labelPairs(h.values, ""), format(h.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", v.metricName, v.labelPairs(h.values, ""), h.count)
+	}
+}
+
+func format(f float64) string {
+	if math.IsInf(f, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+func escape(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
@@ -0,0 +1,93 @@
+package metrics
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// written => the lines Write produces for the metric family named name
+func written(t *testing.T, name string) string {
+	var buf bytes.Buffer
+	if err := Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	var lines []string
+	for _, line := range strings.Split(buf.String(), "\n") {
+		if strings.HasPrefix(line, name) || strings.HasPrefix(line, "# HELP "+name+" ") || strings.HasPrefix(line, "# TYPE "+name+" ") {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestCounterVec(t *testing.T) {
+	v := NewCounterVec("test_requests_total", "Requests handled,\nby method.", "method", "path")
+	v.Inc("GET", "/")
+	v.Add(2.5, "GET", "/")
+	v.Inc("POST", `C:\temp "quoted"`+"\nnext")
+
+	want := `# HELP test_requests_total Requests handled,\nby method.
+# TYPE test_requests_total counter
+test_requests_total{method="GET",path="/"} 3.5
+test_requests_total{method="POST",path="C:\\temp \"quoted\"\nnext"} 1`
+	if got := written(t, "test_requests_total"); got != want {
+		t.Errorf("wrote\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestHistogramVec(t *testing.T) {
+	v := NewHistogramVec("test_duration_seconds", "Durations.", []float64{1, 0.1, 0.5}, "route")
+	for _, value := range []float64{0.05, 0.1, 0.3, 0.7, 0.9, 5} {
+		v.Observe(value, "/a")
+	}
+	v.Observe(2, "/b")
+
+	// buckets are sorted and cumulative; +Inf counts every observation, including those above the last bound
+	want := `# HELP test_duration_seconds Durations.
+# TYPE test_duration_seconds histogram
+test_duration_seconds_bucket{route="/a",le="0.1"} 2
+test_duration_seconds_bucket{route="/a",le="0.5"} 3
+test_duration_seconds_bucket{route="/a",le="1"} 5
+test_duration_seconds_bucket{route="/a",le="+Inf"} 6
+test_duration_seconds_sum{route="/a"} 7.05
+test_duration_seconds_count{route="/a"} 6
+test_duration_seconds_bucket{route="/b",le="0.1"} 0
+test_duration_seconds_bucket{route="/b",le="0.5"} 0
+test_duration_seconds_bucket{route="/b",le="1"} 0
+test_duration_seconds_bucket{route="/b",le="+Inf"} 1
+test_duration_seconds_sum{route="/b"} 2
+test_duration_seconds_count{route="/b"} 1`
+	if got := written(t, "test_duration_seconds"); got != want {
+		t.Errorf("wrote\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestUnlabeled(t *testing.T) {
+	v := NewCounterVec("test_unlabeled_total", "Events.")
+	v.Inc()
+	if got, want := written(t, "test_unlabeled_total"), "# HELP test_unlabeled_total Events.\n# TYPE test_unlabeled_total counter\ntest_unlabeled_total 1"; got != want {
+		t.Errorf("wrote\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestRegisterDuplicate(t *testing.T) {
+	NewCounterVec("test_duplicate_total", "Once.")
+	defer func() {
+		if recover() == nil {
+			t.Error("registering a name twice did not panic")
+		}
+	}()
+	NewHistogramVec("test_duplicate_total", "Twice.", nil)
+}
+
+func TestWrongLabelCount(t *testing.T) {
+	v := NewCounterVec("test_labels_total", "Labeled.", "method")
+	defer func() {
+		if recover() == nil {
+			t.Error("Inc with too many label values did not panic")
+		}
+	}()
+	v.Inc("GET", "200")
+}
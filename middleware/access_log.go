@@ -0,0 +1,111 @@
+package middleware
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/go-martini/martini"
+	"github.com/obieq/rva-devops-api/logger"
+	"github.com/twinj/uuid"
+)
+
+// REQUEST_ID_HEADER => carries the request id in both directions
+const REQUEST_ID_HEADER string = "X-Request-ID"
+
+// DEFAULT_REDACTED_HEADERS => request headers whose values never reach the access log
+var DEFAULT_REDACTED_HEADERS = []string{"Authorization", "Proxy-Authorization", "Cookie", "X-Api-Key"}
+
+// RequestID => id of the current request, mapped into each request's injector by AccessLog
+type RequestID string
+
+var routeType = reflect.TypeOf((*martini.Route)(nil)).Elem()
+
+// AccessLog => writes one JSON line per request (see logger.Record) with its id, method, route pattern,
+// status, latency and response size; 5xx responses are logged as errors and 4xx as warnings
+// NOTE: register first so the line covers every other handler; request headers are only logged at the
+// debug level, with the values of DEFAULT_REDACTED_HEADERS and redact replaced
+func AccessLog(redact ...string) martini.Handler {
+	redacted := make(map[string]bool)
+	for _, name := range append(DEFAULT_REDACTED_HEADERS, redact...) {
+		redacted[http.CanonicalHeaderKey(name)] = true
+	}
+
+	return func(c martini.Context, res http.ResponseWriter, req *http.Request) {
+		start := time.Now()
+
+		id := req.Header.Get(REQUEST_ID_HEADER)
+		if !validRequestID(id) {
+			id = uuid.NewV4().String()
+		}
+		res.Header().Set(REQUEST_ID_HEADER, id)
+		c.Map(RequestID(id))
+
+		c.Next()
+
+		rw := res.(martini.ResponseWriter)
+		status := rw.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+
+		fields := logger.Fields{
+			"request_id": id,
+			"method":     req.Method,
+			"path":       req.URL.Path,
+			"route":      routePattern(c),
+			"status":     status,
+			"latency_ms": float64(time.Since(start).Nanoseconds()) / 1e6,
+			"bytes":      rw.Size(),
+			"remote":     req.RemoteAddr,
+		}
+		if logger.Enabled(logger.DEBUG) {
+			fields["headers"] = redactHeaders(req.Header, redacted)
+		}
+
+		level := logger.INFO
+		switch {
+		case status >= 500:
+			level = logger.ERROR
+		case status >= 400:
+			level = logger.WARN
+		}
+		logger.Record(level, "request", fields)
+	}
+}
+
+// routePattern => pattern of the route that handled the request, e.g. "/api/v1/automobiles/:id"; "" if none matched
+func routePattern(c martini.Context) string {
+	if v := c.Get(routeType); v.IsValid() {
+		if route, ok := v.Interface().(martini.Route); ok && route != nil {
+			return route.Pattern()
+		}
+	}
+	return ""
+}
+
+// validRequestID => whether a client-supplied id is safe to echo and log
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for _, r := range id {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_.", r)) {
+			return false
+		}
+	}
+	return true
+}
+
+func redactHeaders(header http.Header, redacted map[string]bool) map[string]string {
+	headers := make(map[string]string, len(header))
+	for name, values := range header {
+		if redacted[name] {
+			headers[name] = "[REDACTED]"
+		} else {
+			headers[name] = strings.Join(values, ", ")
+		}
+	}
+	return headers
+}
@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/go-martini/martini"
+	"github.com/obieq/rva-devops-api/resources"
+)
+
+// APIRoot => maps a resources.APIRoot into each request's injector so handlers can build links
+// NOTE: when baseURL is empty, the root is derived from the request (see RequestBaseURL)
+func APIRoot(baseURL string) martini.Handler {
+	return func(c martini.Context, req *http.Request) {
+		base := baseURL
+		if base == "" {
+			base = RequestBaseURL(req)
+		}
+		c.Map(resources.NewAPIRoot(base))
+	}
+}
+
+// RequestBaseURL => scheme + host the client used, honoring X-Forwarded-Proto and X-Forwarded-Host
+func RequestBaseURL(req *http.Request) string {
+	scheme := "http"
+	if req.TLS != nil {
+		scheme = "https"
+	}
+	if proto := firstHeaderValue(req, "X-Forwarded-Proto"); proto != "" {
+		scheme = strings.ToLower(proto)
+	}
+
+	host := req.Host
+	if fwdHost := firstHeaderValue(req, "X-Forwarded-Host"); fwdHost != "" {
+		host = fwdHost
+	}
+
+	return scheme + "://" + host
+}
+
+// firstHeaderValue => proxies may append to X-Forwarded-* headers; the first value is the client-facing one
+func firstHeaderValue(req *http.Request, name string) string {
+	v := req.Header.Get(name)
+	if i := strings.Index(v, ","); i >= 0 {
+		v = v[:i]
+	}
+	return strings.TrimSpace(v)
+}
@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/go-martini/martini"
+	"github.com/martini-contrib/render"
+	"github.com/obieq/rva-devops-api/auth"
+	"github.com/obieq/rva-devops-api/controllers"
+	"github.com/obieq/rva-devops-api/resources"
+)
+
+// API_KEY_HEADER => alternative to "Authorization: Bearer <token>" for clients that cannot set Authorization
+const API_KEY_HEADER string = "X-API-Key"
+
+var (
+	errMissingCredentials = errors.New("an api key is required: send Authorization: Bearer TOKEN or " + API_KEY_HEADER + ": TOKEN")
+	errInvalidCredentials = errors.New("the api key is invalid or has been revoked")
+)
+
+// Authenticate => requires an api key on requests under resources.API_PATH and maps the caller's
+// *auth.Principal into the request's injector; responds 401 otherwise
+// NOTE: register after render.Renderer
+func Authenticate() martini.Handler {
+	return func(c martini.Context, req *http.Request, r render.Render) {
+		if !strings.HasPrefix(req.URL.Path, resources.API_PATH) {
+			return
+		}
+
+		token := credentials(req)
+		if token == "" {
+			unauthorized(errMissingCredentials, r)
+			return
+		}
+
+		principal, err := auth.Authenticate(token)
+		if err == auth.ErrInvalidKey {
+			unauthorized(errInvalidCredentials, r)
+			return
+		} else if err != nil {
+			controllers.HandleBackendError(err, r)
+			return
+		}
+
+		c.Map(principal)
+	}
+}
+
+// credentials => the token from a bearer Authorization header or the X-API-Key header
+func credentials(req *http.Request) string {
+	if authorization := req.Header.Get("Authorization"); authorization != "" {
+		if len(authorization) > 7 && strings.EqualFold(authorization[:7], "bearer ") {
+			return strings.TrimSpace(authorization[7:])
+		}
+		return ""
+	}
+	return strings.TrimSpace(req.Header.Get(API_KEY_HEADER))
+}
+
+func unauthorized(err error, r render.Render) {
+	r.Header().Set("WWW-Authenticate", `Bearer realm="api"`)
+	controllers.HandleError(401, err, r)
+}
@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/go-martini/martini"
+	"github.com/martini-contrib/render"
+	"github.com/obieq/rva-devops-api/auth"
+	"github.com/obieq/rva-devops-api/controllers"
+	"github.com/obieq/rva-devops-api/logger"
+	"github.com/obieq/rva-devops-api/resources"
+)
+
+var principalType = reflect.TypeOf((*auth.Principal)(nil))
+
+var errForbidden = errors.New("your api key's role does not allow this request")
+
+// Authorize => lets requests under resources.API_PATH through only if the policy grants the caller's role
+// access to the method and path; responds 403 otherwise and records the denial in the audit trail
+// (see logger.Audit), whatever the log level
+// NOTE: register after Authenticate (or after mapping a *auth.Principal) and AccessLog
+func Authorize(policy auth.Policy) martini.Handler {
+	return func(c martini.Context, id RequestID, req *http.Request, r render.Render) {
+		if !strings.HasPrefix(req.URL.Path, resources.API_PATH) {
+			return
+		}
+		// the principal is only mapped once Authenticate accepted the request
+		principal := c.Get(principalType).Interface().(*auth.Principal)
+		if policy.Allowed(req.Method, req.URL.Path, principal.Role) {
+			return
+		}
+
+		logger.Audit("access-denied", logger.Fields{
+			"request_id": string(id),
+			"principal":  principal.Name,
+			"key_id":     principal.KeyID,
+			"role":       principal.Role,
+			"method":     req.Method,
+			"path":       req.URL.Path,
+			"remote":     req.RemoteAddr,
+		})
+		controllers.HandleError(403, errForbidden, r)
+	}
+}
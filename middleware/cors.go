@@ -0,0 +1,109 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-martini/martini"
+)
+
+// DEFAULT_CORS_ALLOW_HEADERS => request headers browsers may send cross-origin
+var DEFAULT_CORS_ALLOW_HEADERS = []string{"Accept", "Authorization", "Content-Type", "If-Match", "If-Modified-Since", "If-None-Match", API_KEY_HEADER, REQUEST_ID_HEADER}
+
+// DEFAULT_CORS_EXPOSE_HEADERS => response headers scripts may read cross-origin, beyond the CORS-safelisted ones
+var DEFAULT_CORS_EXPOSE_HEADERS = []string{"Location", "ETag", "Last-Modified", REQUEST_ID_HEADER, "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "Retry-After"}
+
+// CORSOptions => which cross-origin requests browsers may make
+type CORSOptions struct {
+	// AllowOrigins => origins, e.g. "https://app.example.com", or "*" for any; empty allows none
+	AllowOrigins []string
+	// AllowMethods => methods offered in preflight responses; empty offers every method routed for the path
+	AllowMethods []string
+	AllowHeaders []string
+	// ExposeHeaders => response headers scripts may read
+	ExposeHeaders    []string
+	AllowCredentials bool
+	// MaxAge => how long browsers may cache a preflight response; 0 leaves it to the browser
+	MaxAge time.Duration
+}
+
+// CORS => adds CORS headers to requests from allowed origins and answers their preflight (OPTIONS) requests
+// with the methods martini routes for the path (see martini.Routes.MethodsFor)
+// NOTE: register before MediaTypes and Authenticate, since browsers send preflights without Content-Type,
+// Accept or credentials
+func CORS(opts CORSOptions) martini.Handler {
+	anyOrigin := contains(opts.AllowOrigins, "*")
+	allowHeaders := strings.Join(opts.AllowHeaders, ", ")
+	exposeHeaders := strings.Join(opts.ExposeHeaders, ", ")
+
+	return func(res http.ResponseWriter, req *http.Request, routes martini.Routes) {
+		origin := req.Header.Get("Origin")
+		header := res.Header()
+		header.Add("Vary", "Origin")
+		if origin == "" || !anyOrigin && !contains(opts.AllowOrigins, origin) {
+			return
+		}
+
+		// credentials cannot be combined with the "*" wildcard, so the origin is echoed instead
+		if anyOrigin && !opts.AllowCredentials {
+			header.Set("Access-Control-Allow-Origin", "*")
+		} else {
+			header.Set("Access-Control-Allow-Origin", origin)
+		}
+		if opts.AllowCredentials {
+			header.Set("Access-Control-Allow-Credentials", "true")
+		}
+
+		if req.Method != "OPTIONS" || req.Header.Get("Access-Control-Request-Method") == "" {
+			if exposeHeaders != "" {
+				header.Set("Access-Control-Expose-Headers", exposeHeaders)
+			}
+			return
+		}
+
+		// preflight; requests for paths without routes are left to the rest of the chain
+		methods := allowedMethods(routes.MethodsFor(req.URL.Path), opts.AllowMethods)
+		if len(methods) == 0 {
+			return
+		}
+
+		header.Set("Access-Control-Allow-Methods", strings.Join(methods, ", "))
+		if allowHeaders != "" {
+			header.Set("Access-Control-Allow-Headers", allowHeaders)
+		} else if requested := req.Header.Get("Access-Control-Request-Headers"); requested != "" {
+			header.Set("Access-Control-Allow-Headers", requested)
+		}
+		if opts.MaxAge > 0 {
+			header.Set("Access-Control-Max-Age", strconv.Itoa(int(opts.MaxAge.Seconds())))
+		}
+		res.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// allowedMethods => the routed methods, plus HEAD for GET (martini serves HEAD with GET routes), limited to allow
+func allowedMethods(routed, allow []string) []string {
+	var methods []string
+	for _, method := range routed {
+		if method == "*" {
+			return allow
+		}
+		if len(allow) == 0 || contains(allow, method) {
+			methods = append(methods, method)
+		}
+		if method == "GET" && !contains(routed, "HEAD") && (len(allow) == 0 || contains(allow, "HEAD")) {
+			methods = append(methods, "HEAD")
+		}
+	}
+	return methods
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,121 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-martini/martini"
+)
+
+// corsServer => routes GET and PATCH for /things/:id behind CORS(opts)
+func corsServer(opts CORSOptions) http.Handler {
+	router := martini.NewRouter()
+	router.Get("/things/:id", func() string { return "thing" })
+	router.Patch("/things/:id", func() string { return "patched" })
+
+	m := martini.New()
+	m.MapTo(router, (*martini.Routes)(nil))
+	m.Use(CORS(opts))
+	m.Action(router.Handle)
+	return m
+}
+
+func cors(handler http.Handler, method, origin string, headers map[string]string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(method, "/things/1", nil)
+	if origin != "" {
+		req.Header.Set("Origin", origin)
+	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, req)
+	return res
+}
+
+func TestCORSPreflight(t *testing.T) {
+	preflight := map[string]string{"Access-Control-Request-Method": "PATCH", "Access-Control-Request-Headers": "X-Custom"}
+
+	tests := []struct {
+		name         string
+		opts         CORSOptions
+		methods      string
+		allowHeaders string
+		maxAge       string
+	}{
+		{"routed methods, with HEAD for GET", CORSOptions{AllowOrigins: []string{"*"}}, "GET, HEAD, PATCH", "X-Custom", ""},
+		{"limited to AllowMethods", CORSOptions{AllowOrigins: []string{"*"}, AllowMethods: []string{"GET", "POST"}}, "GET", "X-Custom", ""},
+		{"configured headers and max age", CORSOptions{AllowOrigins: []string{"*"}, AllowHeaders: []string{"Accept", "If-Match"}, MaxAge: 10 * time.Minute}, "GET, HEAD, PATCH", "Accept, If-Match", "600"},
+	}
+
+	for _, test := range tests {
+		res := cors(corsServer(test.opts), "OPTIONS", "https://app.example.com", preflight)
+		if res.Code != 204 {
+			t.Errorf("%s: status %d, want 204", test.name, res.Code)
+		}
+		for header, want := range map[string]string{
+			"Access-Control-Allow-Methods": test.methods,
+			"Access-Control-Allow-Headers": test.allowHeaders,
+			"Access-Control-Max-Age":       test.maxAge,
+		} {
+			if got := res.Header().Get(header); got != want {
+				t.Errorf("%s: %s %q, want %q", test.name, header, got, want)
+			}
+		}
+	}
+
+	// an OPTIONS request that is not a preflight goes to the routes, which do not handle it
+	res := cors(corsServer(CORSOptions{AllowOrigins: []string{"*"}}), "OPTIONS", "https://app.example.com", nil)
+	if res.Header().Get("Access-Control-Allow-Methods") != "" {
+		t.Error("answered an OPTIONS request without Access-Control-Request-Method as a preflight")
+	}
+}
+
+func TestCORSOrigins(t *testing.T) {
+	tests := []struct {
+		name, origin string
+		opts         CORSOptions
+		allowOrigin  string
+		credentials  string
+	}{
+		{"any origin", "https://app.example.com", CORSOptions{AllowOrigins: []string{"*"}}, "*", ""},
+		{"any origin with credentials echoes the origin", "https://app.example.com", CORSOptions{AllowOrigins: []string{"*"}, AllowCredentials: true}, "https://app.example.com", "true"},
+		{"listed origin", "https://app.example.com", CORSOptions{AllowOrigins: []string{"https://other.example.com", "https://app.example.com"}}, "https://app.example.com", ""},
+		{"unlisted origin", "https://evil.example.com", CORSOptions{AllowOrigins: []string{"https://app.example.com"}, AllowCredentials: true}, "", ""},
+		{"no origins allowed", "https://app.example.com", CORSOptions{}, "", ""},
+		{"same-origin request", "", CORSOptions{AllowOrigins: []string{"*"}}, "", ""},
+	}
+
+	for _, test := range tests {
+		opts := test.opts
+		opts.ExposeHeaders = []string{"ETag"}
+		handler := corsServer(opts)
+
+		for _, method := range []string{"GET", "OPTIONS"} {
+			res := cors(handler, method, test.origin, map[string]string{"Access-Control-Request-Method": "GET"})
+			if got := res.Header().Get("Access-Control-Allow-Origin"); got != test.allowOrigin {
+				t.Errorf("%s, %s: Access-Control-Allow-Origin %q, want %q", test.name, method, got, test.allowOrigin)
+			}
+			if got := res.Header().Get("Access-Control-Allow-Credentials"); got != test.credentials {
+				t.Errorf("%s, %s: Access-Control-Allow-Credentials %q, want %q", test.name, method, got, test.credentials)
+			}
+			if vary := res.Header().Get("Vary"); vary != "Origin" {
+				t.Errorf("%s, %s: Vary %q, want Origin", test.name, method, vary)
+			}
+			if test.allowOrigin == "" {
+				for _, header := range []string{"Access-Control-Allow-Methods", "Access-Control-Allow-Headers", "Access-Control-Expose-Headers"} {
+					if got := res.Header().Get(header); got != "" {
+						t.Errorf("%s, %s: %s %q for a disallowed origin", test.name, method, header, got)
+					}
+				}
+			}
+		}
+
+		res := cors(handler, "GET", test.origin, nil)
+		if want := map[bool]string{true: "", false: "ETag"}[test.allowOrigin == ""]; res.Header().Get("Access-Control-Expose-Headers") != want {
+			t.Errorf("%s: Access-Control-Expose-Headers %q, want %q", test.name, res.Header().Get("Access-Control-Expose-Headers"), want)
+		}
+	}
+}
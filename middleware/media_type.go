@@ -0,0 +1,200 @@
+package middleware
+
+import (
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/go-martini/martini"
+	"github.com/obieq/rva-devops-api/resources"
+)
+
+// JSON_API_MEDIA_TYPE => media type of JSON API request and response documents
+const JSON_API_MEDIA_TYPE string = "application/vnd.api+json"
+
+// Negotiator => a media type the API reads and writes
+type Negotiator interface {
+	// MediaType => e.g. "application/vnd.api+json"
+	MediaType() string
+	// AcceptParams => whether the media type may carry these parameters, in Content-Type or Accept ("q" excluded)
+	AcceptParams(params map[string]string) bool
+}
+
+// JsonApiMediaType => JSON API forbids media type parameters in both directions
+type JsonApiMediaType struct{}
+
+func (JsonApiMediaType) MediaType() string {
+	return JSON_API_MEDIA_TYPE
+}
+
+func (JsonApiMediaType) AcceptParams(params map[string]string) bool {
+	return len(params) == 0
+}
+
+// JsonMediaType => plain application/json, for clients that do not speak JSON API; only charset is allowed
+type JsonMediaType struct{}
+
+func (JsonMediaType) MediaType() string {
+	return "application/json"
+}
+
+func (JsonMediaType) AcceptParams(params map[string]string) bool {
+	for name, value := range params {
+		if name != "charset" || !strings.EqualFold(value, "utf-8") {
+			return false
+		}
+	}
+	return true
+}
+
+// MediaTypes => content negotiation for requests under resources.API_PATH
+//
+//	415 => the request body's Content-Type is not one of the negotiators' or carries parameters they reject
+//	406 => Accept excludes every negotiator's media type
+//
+// The first negotiator is the default when Accept is absent or a wildcard. The chosen negotiator is mapped
+// into the request's injector and its media type replaces application/json on the response.
+// NOTE: register before render.Renderer so the renderer writes through the negotiated response writer
+func MediaTypes(negotiators ...Negotiator) martini.Handler {
+	if len(negotiators) == 0 {
+		panic("middleware: MediaTypes requires at least one negotiator")
+	}
+
+	return func(c martini.Context, res http.ResponseWriter, req *http.Request) {
+		if !strings.HasPrefix(req.URL.Path, resources.API_PATH) {
+			return
+		}
+
+		if hasBody(req) && !supportedContentType(req.Header.Get("Content-Type"), negotiators) {
+			writeError(res, 415, "Content-Type must be one of "+mediaTypeList(negotiators)+" without media type parameters")
+			return
+		}
+
+		negotiator := acceptable(req.Header[http.CanonicalHeaderKey("Accept")], negotiators)
+		if negotiator == nil {
+			writeError(res, 406, "Accept must allow one of "+mediaTypeList(negotiators)+" without media type parameters")
+			return
+		}
+
+		c.MapTo(negotiator, (*Negotiator)(nil))
+		c.MapTo(&mediaTypeWriter{ResponseWriter: res, mediaType: negotiator.MediaType()}, (*http.ResponseWriter)(nil))
+	}
+}
+
+// hasBody => whether the request carries a body, and therefore a Content-Type to check
+func hasBody(req *http.Request) bool {
+	return req.ContentLength > 0 || len(req.TransferEncoding) > 0
+}
+
+func supportedContentType(contentType string, negotiators []Negotiator) bool {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	for _, n := range negotiators {
+		if n.MediaType() == mediaType {
+			return n.AcceptParams(params)
+		}
+	}
+	return false
+}
+
+// acceptable => the negotiator for the first Accept entry that allows one, or nil
+// NOTE: per JSON API, a media type that is only ever accepted with parameters rules out wildcards too
+func acceptable(accept []string, negotiators []Negotiator) Negotiator {
+	var entries []string
+	for _, header := range accept {
+		for _, entry := range strings.Split(header, ",") {
+			if entry = strings.TrimSpace(entry); entry != "" {
+				entries = append(entries, entry)
+			}
+		}
+	}
+	if len(entries) == 0 {
+		return negotiators[0]
+	}
+
+	wildcard := false
+	listed := make(map[string]bool)   // media type appears in Accept
+	accepted := make(map[string]bool) // media type appears in Accept with acceptable parameters
+	var first Negotiator
+
+	for _, entry := range entries {
+		mediaType, params, err := mime.ParseMediaType(entry)
+		if err != nil {
+			continue
+		}
+		delete(params, "q")
+
+		if mediaType == "*/*" || mediaType == "application/*" {
+			wildcard = true
+			continue
+		}
+
+		for _, n := range negotiators {
+			if n.MediaType() != mediaType {
+				continue
+			}
+			listed[mediaType] = true
+			if n.AcceptParams(params) {
+				accepted[mediaType] = true
+				if first == nil {
+					first = n
+				}
+			}
+		}
+	}
+
+	for mediaType := range listed {
+		if !accepted[mediaType] {
+			return nil
+		}
+	}
+	if first == nil && wildcard {
+		first = negotiators[0]
+	}
+
+	return first
+}
+
+func mediaTypeList(negotiators []Negotiator) string {
+	types := make([]string, len(negotiators))
+	for i, n := range negotiators {
+		types[i] = n.MediaType()
+	}
+	return strings.Join(types, ", ")
+}
+
+// writeError => renders a JSON API error document, for handlers that run before render.Renderer and so cannot
+// use render.Render
+func writeError(res http.ResponseWriter, status int, detail string) {
+	res.Header().Set("Content-Type", JSON_API_MEDIA_TYPE)
+	res.WriteHeader(status)
+	json.NewEncoder(res).Encode(map[string]interface{}{"errors": []resources.Error{resources.NewError(status, detail)}})
+}
+
+// mediaTypeWriter => replaces the application/json Content-Type set by render.JSON with the negotiated media type
+type mediaTypeWriter struct {
+	http.ResponseWriter
+	mediaType   string
+	wroteHeader bool
+}
+
+func (w *mediaTypeWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		if contentType := w.Header().Get("Content-Type"); strings.HasPrefix(contentType, "application/json") {
+			w.Header().Set("Content-Type", w.mediaType)
+		}
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *mediaTypeWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(b)
+}
@@ -0,0 +1,121 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-martini/martini"
+)
+
+var negotiators = []Negotiator{JsonApiMediaType{}, JsonMediaType{}}
+
+func TestAcceptable(t *testing.T) {
+	for _, test := range []struct {
+		accept []string
+		want   string // media type of the chosen negotiator; "" => 406
+	}{
+		{nil, JSON_API_MEDIA_TYPE},
+		{[]string{""}, JSON_API_MEDIA_TYPE},
+		{[]string{"*/*"}, JSON_API_MEDIA_TYPE},
+		{[]string{"application/*"}, JSON_API_MEDIA_TYPE},
+		{[]string{"text/html"}, ""},
+		{[]string{"application/vnd.api+json"}, JSON_API_MEDIA_TYPE},
+		{[]string{"application/json, application/vnd.api+json"}, "application/json"},
+		{[]string{"application/json", "application/vnd.api+json"}, "application/json"},
+		{[]string{"text/html, */*"}, JSON_API_MEDIA_TYPE},
+		{[]string{"application/json;charset=utf-8"}, "application/json"},
+		{[]string{"application/json;charset=latin1"}, ""},
+
+		// q is not a media type parameter, and the order of preference is left to the client
+		{[]string{"application/vnd.api+json;q=0.5"}, JSON_API_MEDIA_TYPE},
+		{[]string{"application/vnd.api+json;q=0.1, application/json;q=0.9"}, JSON_API_MEDIA_TYPE},
+
+		// listed only with parameters => 406, even with a wildcard or another acceptable type
+		{[]string{"application/vnd.api+json;ext=bulk"}, ""},
+		{[]string{"application/vnd.api+json;ext=bulk, */*"}, ""},
+		{[]string{"application/vnd.api+json;ext=bulk, application/json"}, ""},
+
+		// listed once without parameters => acceptable
+		{[]string{"application/vnd.api+json;ext=bulk, application/vnd.api+json"}, JSON_API_MEDIA_TYPE},
+
+		{[]string{"not a media type, */*"}, JSON_API_MEDIA_TYPE},
+	} {
+		got := ""
+		if n := acceptable(test.accept, negotiators); n != nil {
+			got = n.MediaType()
+		}
+		if got != test.want {
+			t.Errorf("acceptable(%q) = %q, want %q", test.accept, got, test.want)
+		}
+	}
+}
+
+func TestSupportedContentType(t *testing.T) {
+	for _, test := range []struct {
+		contentType string
+		want        bool
+	}{
+		{"application/vnd.api+json", true},
+		{"Application/VND.API+JSON", true},
+		{"application/vnd.api+json; ext=bulk", false},
+		{"application/vnd.api+json; charset=utf-8", false},
+		{"application/json", true},
+		{"application/json; charset=UTF-8", true},
+		{"application/json; charset=latin1", false},
+		{"text/plain", false},
+		{"*/*", false},
+		{"", false},
+		{";;", false},
+	} {
+		if got := supportedContentType(test.contentType, negotiators); got != test.want {
+			t.Errorf("supportedContentType(%q) = %v, want %v", test.contentType, got, test.want)
+		}
+	}
+}
+
+func TestMediaTypes(t *testing.T) {
+	m := martini.New()
+	m.Use(MediaTypes(negotiators...))
+	m.Action(func(res http.ResponseWriter) {
+		res.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		res.Write([]byte("{}"))
+	})
+
+	for _, test := range []struct {
+		method, path, body string
+		headers            map[string]string
+		status             int
+		contentType        string
+	}{
+		{"GET", "/api/v1/automobiles", "", nil, 200, JSON_API_MEDIA_TYPE},
+		{"GET", "/api/v1/automobiles", "", map[string]string{"Accept": "application/json"}, 200, "application/json"},
+		{"GET", "/api/v1/automobiles", "", map[string]string{"Accept": "application/vnd.api+json;ext=bulk, */*"}, 406, JSON_API_MEDIA_TYPE},
+
+		// a bodyless request skips the Content-Type check, whatever it claims
+		{"GET", "/api/v1/automobiles", "", map[string]string{"Content-Type": "text/plain"}, 200, JSON_API_MEDIA_TYPE},
+		{"DELETE", "/api/v1/automobiles/1", "", map[string]string{"Content-Type": "application/vnd.api+json; ext=bulk"}, 200, JSON_API_MEDIA_TYPE},
+
+		{"POST", "/api/v1/automobiles", "{}", map[string]string{"Content-Type": JSON_API_MEDIA_TYPE}, 200, JSON_API_MEDIA_TYPE},
+		{"POST", "/api/v1/automobiles", "{}", map[string]string{"Content-Type": "application/vnd.api+json; ext=bulk"}, 415, JSON_API_MEDIA_TYPE},
+		{"POST", "/api/v1/automobiles", "{}", nil, 415, JSON_API_MEDIA_TYPE},
+
+		// outside resources.API_PATH nothing is negotiated
+		{"GET", "/healthz", "", map[string]string{"Accept": "text/html"}, 200, "application/json; charset=UTF-8"},
+	} {
+		req, _ := http.NewRequest(test.method, test.path, strings.NewReader(test.body))
+		for name, value := range test.headers {
+			req.Header.Set(name, value)
+		}
+		res := httptest.NewRecorder()
+		m.ServeHTTP(res, req)
+
+		if res.Code != test.status {
+			t.Errorf("%s %s %v: status %d, want %d", test.method, test.path, test.headers, res.Code, test.status)
+		}
+		if contentType := res.Header().Get("Content-Type"); contentType != test.contentType {
+			t.Errorf("%s %s %v: Content-Type %q, want %q", test.method, test.path, test.headers, contentType, test.contentType)
+		}
+	}
+}
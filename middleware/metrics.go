@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-martini/martini"
+	"github.com/obieq/rva-devops-api/metrics"
+)
+
+// UNMATCHED_ROUTE => route label of requests no route matched, so unknown paths cannot inflate the number of series
+const UNMATCHED_ROUTE string = "unmatched"
+
+// OTHER_METHOD => method label of requests with a method outside of standardMethods, for the same reason
+const OTHER_METHOD string = "other"
+
+var standardMethods = map[string]bool{"GET": true, "HEAD": true, "POST": true, "PUT": true, "PATCH": true, "DELETE": true, "OPTIONS": true}
+
+var (
+	httpRequests = metrics.NewCounterVec("http_requests_total",
+		"HTTP requests by route pattern, method and status.",
+		"route", "method", "status")
+	httpRequestDuration = metrics.NewHistogramVec("http_request_duration_seconds",
+		"Latency of HTTP requests by route pattern, method and status.",
+		nil, "route", "method", "status")
+)
+
+// Metrics => counts each request and records its latency, labeled by route pattern, method and status
+// NOTE: register right after AccessLog so responses written by any other handler are counted
+func Metrics() martini.Handler {
+	return func(c martini.Context, res http.ResponseWriter, req *http.Request) {
+		start := time.Now()
+
+		c.Next()
+
+		status := res.(martini.ResponseWriter).Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		route := routePattern(c)
+		if route == "" {
+			route = UNMATCHED_ROUTE
+		}
+
+		method := req.Method
+		if !standardMethods[method] {
+			method = OTHER_METHOD
+		}
+
+		labels := []string{route, method, strconv.Itoa(status)}
+		httpRequests.Inc(labels...)
+		httpRequestDuration.Observe(time.Since(start).Seconds(), labels...)
+	}
+}
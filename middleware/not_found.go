@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/go-martini/martini"
+	"github.com/martini-contrib/render"
+	"github.com/obieq/rva-devops-api/controllers"
+)
+
+var errNotFound = errors.New("no resource exists at this path")
+
+// NotFound => renders a JSON API 404 for paths without routes, or a 405 with an Allow header listing the
+// routed methods when the path is only routed for other methods
+// NOTE: set with martini.Router.NotFound
+func NotFound(req *http.Request, routes martini.Routes, r render.Render) {
+	methods := allowedMethods(routes.MethodsFor(req.URL.Path), nil)
+	if len(methods) == 0 {
+		controllers.HandleError(404, errNotFound, r)
+		return
+	}
+
+	r.Header().Set("Allow", strings.Join(methods, ", "))
+	controllers.HandleError(405, fmt.Errorf("%s is not allowed here; use one of %s", req.Method, strings.Join(methods, ", ")), r)
+}
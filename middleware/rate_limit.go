@@ -0,0 +1,218 @@
+package middleware
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-martini/martini"
+	"github.com/martini-contrib/render"
+	"github.com/obieq/rva-devops-api/auth"
+	"github.com/obieq/rva-devops-api/controllers"
+)
+
+// SWEEP_INTERVAL => how often buckets that have refilled completely, and so carry no state, are dropped
+const SWEEP_INTERVAL = time.Minute
+
+var errRateLimited = errors.New("too many requests; retry after the number of seconds in the Retry-After header")
+
+// Period => a time.Duration read from JSON as a string, e.g. "1m"
+type Period time.Duration
+
+func (p *Period) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	d, err := time.ParseDuration(s)
+	*p = Period(d)
+	return err
+}
+
+// RateLimit => lets each client send Limit requests per Period to the matching requests, in bursts of up to Limit
+type RateLimit struct {
+	// Methods => HTTP methods the limit applies to; empty or "*" matches any
+	Methods []string `json:"methods"`
+	// Route => path pattern, as in auth.Rule, e.g. "/api/v1/automobiles/**"
+	Route string `json:"route"`
+	// Limit => bucket size; 0 exempts the matching requests
+	Limit  int    `json:"limit"`
+	Period Period `json:"period"`
+}
+
+// RateLimits => ordered limits; the first limit matching a request applies and unmatched requests are not limited
+type RateLimits []RateLimit
+
+// ParseRateLimit => a limit on every route from "<limit>/<period>", e.g. "300/1m"; "" or "0" means no limit
+func ParseRateLimit(s string) (RateLimit, error) {
+	limit := RateLimit{Route: "/**"}
+	if s == "" || s == "0" {
+		return limit, nil
+	}
+
+	i := strings.Index(s, "/")
+	if i < 0 {
+		return limit, fmt.Errorf("rate limit %q: want <limit>/<period>, e.g. 300/1m", s)
+	}
+	n, err := strconv.Atoi(s[:i])
+	if err != nil {
+		return limit, fmt.Errorf("rate limit %q: %v", s, err)
+	}
+	d, err := time.ParseDuration(s[i+1:])
+	if err != nil {
+		return limit, fmt.Errorf("rate limit %q: %v", s, err)
+	}
+
+	limit.Limit, limit.Period = n, Period(d)
+	return limit, limit.validate()
+}
+
+// LoadRateLimits => reads limits from a JSON file holding an array of RateLimit
+func LoadRateLimits(path string) (RateLimits, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var limits RateLimits
+	if err := json.NewDecoder(f).Decode(&limits); err != nil {
+		return nil, fmt.Errorf("rate limits %s: %v", path, err)
+	}
+	for i, limit := range limits {
+		if err := limit.validate(); err != nil {
+			return nil, fmt.Errorf("rate limits %s: limit %d: %v", path, i, err)
+		}
+	}
+	return limits, nil
+}
+
+func (limit RateLimit) validate() error {
+	if limit.Limit < 0 || limit.Limit > 0 && limit.Period <= 0 {
+		return errors.New("limit must not be negative and period must be positive")
+	}
+	return nil
+}
+
+// rate => tokens added per second
+func (limit RateLimit) rate() float64 {
+	return float64(limit.Limit) / time.Duration(limit.Period).Seconds()
+}
+
+// bucket => tokens left for one client under one limit
+type bucket struct {
+	limit   *RateLimit
+	tokens  float64
+	updated time.Time
+}
+
+// refill => adds the tokens earned since the last update
+func (b *bucket) refill(now time.Time) {
+	b.tokens = math.Min(float64(b.limit.Limit), b.tokens+now.Sub(b.updated).Seconds()*b.limit.rate())
+	b.updated = now
+}
+
+// limiter => token buckets per limit and client
+type limiter struct {
+	limits RateLimits
+
+	mu      sync.Mutex
+	buckets map[string]*bucket // limit index + client => bucket
+	swept   time.Time
+}
+
+// take => spends a token of the client's bucket for limits[i]; ok is false if none is left
+func (l *limiter) take(i int, client string, now time.Time) (b bucket, ok bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.swept) >= SWEEP_INTERVAL {
+		l.sweep(now)
+	}
+
+	key := strconv.Itoa(i) + "|" + client
+	current, found := l.buckets[key]
+	if !found {
+		current = &bucket{limit: &l.limits[i], tokens: float64(l.limits[i].Limit), updated: now}
+		l.buckets[key] = current
+	}
+
+	current.refill(now)
+	if ok = current.tokens >= 1; ok {
+		current.tokens--
+	}
+	return *current, ok
+}
+
+func (l *limiter) sweep(now time.Time) {
+	for key, b := range l.buckets {
+		if b.refill(now); b.tokens >= float64(b.limit.Limit) {
+			delete(l.buckets, key)
+		}
+	}
+	l.swept = now
+}
+
+// RateLimiter => token-bucket rate limiting per api key or, for requests without one, per client IP; sends the
+// RateLimit-Limit, RateLimit-Remaining and RateLimit-Reset headers and responds 429 with Retry-After when a
+// client runs out of tokens
+// NOTE: register after Authenticate so requests are counted against their api key, or before it to count every
+// request against its client IP, e.g. to bound requests carrying bad keys. The state is kept in
+// process, so each server instance enforces the limits on its own. Client IPs are taken from the connection,
+// not from X-Forwarded-For, which clients could forge
+func RateLimiter(limits RateLimits) martini.Handler {
+	l := &limiter{limits: limits, buckets: make(map[string]*bucket)}
+
+	return func(c martini.Context, req *http.Request, r render.Render) {
+		i := -1
+		for j, limit := range limits {
+			if auth.MatchMethod(limit.Methods, req.Method) && auth.MatchRoute(limit.Route, req.URL.Path) {
+				i = j
+				break
+			}
+		}
+		if i < 0 || limits[i].Limit == 0 {
+			return
+		}
+
+		b, ok := l.take(i, client(c, req), time.Now())
+
+		header := r.Header()
+		header.Set("RateLimit-Limit", strconv.Itoa(b.limit.Limit))
+		header.Set("RateLimit-Remaining", strconv.Itoa(int(b.tokens)))
+		header.Set("RateLimit-Reset", seconds((float64(b.limit.Limit)-b.tokens)/b.limit.rate()))
+
+		if !ok {
+			header.Set("Retry-After", seconds((1-b.tokens)/b.limit.rate()))
+			controllers.HandleError(429, errRateLimited, r)
+		}
+	}
+}
+
+// client => "key:<id>" for requests authenticated with an api key, "ip:<address>" otherwise
+func client(c martini.Context, req *http.Request) string {
+	if v := c.Get(principalType); v.IsValid() {
+		if principal, _ := v.Interface().(*auth.Principal); principal != nil && principal.KeyID != "" {
+			return "key:" + principal.KeyID
+		}
+	}
+
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		host = req.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+// seconds => a delay in whole seconds, rounded up
+func seconds(s float64) string {
+	return strconv.Itoa(int(math.Ceil(s)))
+}
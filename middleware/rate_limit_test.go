@@ -0,0 +1,135 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-martini/martini"
+	"github.com/martini-contrib/render"
+)
+
+func TestParseRateLimit(t *testing.T) {
+	tests := []struct {
+		s      string
+		limit  int
+		period time.Duration
+		valid  bool
+	}{
+		{"300/1m", 300, time.Minute, true},
+		{"5/1s", 5, time.Second, true},
+		{"", 0, 0, true},
+		{"0", 0, 0, true},
+		{"300", 0, 0, false},
+		{"x/1m", 0, 0, false},
+		{"300/soon", 0, 0, false},
+		{"-1/1m", 0, 0, false},
+		{"10/0s", 0, 0, false},
+	}
+
+	for _, test := range tests {
+		limit, err := ParseRateLimit(test.s)
+		if (err == nil) != test.valid {
+			t.Errorf("ParseRateLimit(%q) error = %v, want valid = %v", test.s, err, test.valid)
+			continue
+		}
+		if test.valid && (limit.Limit != test.limit || time.Duration(limit.Period) != test.period || limit.Route != "/**") {
+			t.Errorf("ParseRateLimit(%q) = %+v, want %d per %v on /**", test.s, limit, test.limit, test.period)
+		}
+	}
+}
+
+func TestLimiterRefill(t *testing.T) {
+	l := &limiter{limits: RateLimits{{Route: "/**", Limit: 2, Period: Period(time.Second)}}, buckets: make(map[string]*bucket)}
+	now := time.Now()
+
+	for i := 0; i < 2; i++ {
+		if _, ok := l.take(0, "ip:a", now); !ok {
+			t.Fatalf("request %d of a full bucket was limited", i+1)
+		}
+	}
+	if b, ok := l.take(0, "ip:a", now); ok || b.tokens != 0 {
+		t.Fatalf("third request in a burst of 2: ok = %v with %v tokens left, want it limited with 0", ok, b.tokens)
+	}
+
+	// buckets are per client
+	if _, ok := l.take(0, "ip:b", now); !ok {
+		t.Fatal("another client's request was limited")
+	}
+
+	// 2 tokens per second: one is back after half a second, but not before
+	if _, ok := l.take(0, "ip:a", now.Add(400*time.Millisecond)); ok {
+		t.Fatal("request after 400ms was let through, want a token only after 500ms")
+	}
+	if _, ok := l.take(0, "ip:a", now.Add(500*time.Millisecond)); !ok {
+		t.Fatal("request after 500ms was limited, want a refilled token")
+	}
+
+	// refills never exceed the bucket size
+	b, ok := l.take(0, "ip:a", now.Add(time.Hour))
+	if !ok || b.tokens != 1 {
+		t.Fatalf("request after an hour: ok = %v with %v tokens left, want 1 of 2", ok, b.tokens)
+	}
+}
+
+func TestLimiterSweep(t *testing.T) {
+	l := &limiter{limits: RateLimits{{Route: "/**", Limit: 2, Period: Period(time.Second)}}, buckets: make(map[string]*bucket)}
+	now := time.Now()
+
+	l.take(0, "ip:a", now)
+	l.take(0, "ip:b", now.Add(SWEEP_INTERVAL))
+	if _, found := l.buckets["0|ip:a"]; found {
+		t.Error("a refilled bucket outlived the sweep")
+	}
+	if _, found := l.buckets["0|ip:b"]; !found {
+		t.Error("a bucket with spent tokens was swept")
+	}
+}
+
+func TestRateLimiterHeaders(t *testing.T) {
+	m := martini.New()
+	m.Use(render.Renderer())
+	m.Use(RateLimiter(RateLimits{
+		{Route: "/healthz"},
+		{Methods: []string{"POST"}, Route: "/**", Limit: 1, Period: Period(time.Minute)},
+		{Route: "/**", Limit: 2, Period: Period(time.Minute)},
+	}))
+	m.Action(func() string { return "ok" })
+
+	serve := func(method, path string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, nil)
+		req.RemoteAddr = "192.0.2.1:1234"
+		res := httptest.NewRecorder()
+		m.ServeHTTP(res, req)
+		return res
+	}
+
+	res := serve("GET", "/")
+	if res.Code != 200 || res.Header().Get("RateLimit-Limit") != "2" || res.Header().Get("RateLimit-Remaining") != "1" || res.Header().Get("RateLimit-Reset") != "30" {
+		t.Fatalf("first request: %d with limit %q, remaining %q and reset %q, want 200 with 2, 1 and 30", res.Code,
+			res.Header().Get("RateLimit-Limit"), res.Header().Get("RateLimit-Remaining"), res.Header().Get("RateLimit-Reset"))
+	}
+
+	serve("GET", "/")
+	res = serve("GET", "/")
+	if res.Code != 429 || res.Header().Get("RateLimit-Remaining") != "0" || res.Header().Get("Retry-After") != "30" {
+		t.Fatalf("third request: %d with remaining %q and Retry-After %q, want 429 with 0 and 30", res.Code,
+			res.Header().Get("RateLimit-Remaining"), res.Header().Get("Retry-After"))
+	}
+	if contentType := res.Header().Get("Content-Type"); contentType != "application/json; charset=UTF-8" {
+		t.Errorf("429 Content-Type = %q, want a JSON error document", contentType)
+	}
+
+	// the first matching limit applies
+	if res := serve("POST", "/"); res.Code != 200 || res.Header().Get("RateLimit-Limit") != "1" {
+		t.Errorf("POST: %d with limit %q, want 200 with the POST limit of 1", res.Code, res.Header().Get("RateLimit-Limit"))
+	}
+
+	// exempt routes are never limited and carry no headers
+	for i := 0; i < 3; i++ {
+		if res := serve("GET", "/healthz"); res.Code != 200 || res.Header().Get("RateLimit-Limit") != "" {
+			t.Fatalf("exempt request %d: %d with limit %q, want 200 without headers", i+1, res.Code, res.Header().Get("RateLimit-Limit"))
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/go-martini/martini"
+	"github.com/obieq/rva-devops-api/logger"
+)
+
+var errInternal = errors.New("the server failed to handle the request")
+
+// Recovery => replaces martini.Recovery: logs a panic with its stack and, unless the response was already
+// started, renders a JSON API 500 without the panic's details
+// NOTE: register after AccessLog and Metrics, so panicking requests are logged and counted as 500s
+func Recovery() martini.Handler {
+	return func(c martini.Context, res http.ResponseWriter) {
+		defer func() {
+			if err := recover(); err != nil {
+				logger.Error("panic:", err, "\n"+string(debug.Stack()))
+
+				if rw, ok := res.(martini.ResponseWriter); !ok || !rw.Written() {
+					writeError(res, http.StatusInternalServerError, errInternal.Error())
+				}
+			}
+		}()
+
+		c.Next()
+	}
+}
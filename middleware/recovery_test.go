@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-martini/martini"
+	"github.com/obieq/rva-devops-api/resources"
+)
+
+func TestRecovery(t *testing.T) {
+	m := martini.New()
+	m.Use(Recovery())
+	m.Action(func() { panic("secret internals") })
+
+	req, _ := http.NewRequest("GET", "/", nil)
+	res := httptest.NewRecorder()
+	m.ServeHTTP(res, req)
+
+	if res.Code != 500 || res.Header().Get("Content-Type") != JSON_API_MEDIA_TYPE {
+		t.Fatalf("%d %q, want a 500 JSON API document", res.Code, res.Header().Get("Content-Type"))
+	}
+	var document struct {
+		Errors []resources.Error `json:"errors"`
+	}
+	if err := json.Unmarshal(res.Body.Bytes(), &document); err != nil || len(document.Errors) != 1 || document.Errors[0].Status != "500" {
+		t.Errorf("body %s, want one 500 error object", res.Body.String())
+	}
+	if strings.Contains(res.Body.String(), "secret internals") {
+		t.Error("the panic's value reached the client")
+	}
+}
+
+func TestRecoveryAfterWriting(t *testing.T) {
+	m := martini.New()
+	m.Use(Recovery())
+	m.Action(func(res http.ResponseWriter) {
+		res.WriteHeader(202)
+		res.Write([]byte("partial"))
+		panic("late")
+	})
+
+	req, _ := http.NewRequest("GET", "/", nil)
+	res := httptest.NewRecorder()
+	m.ServeHTTP(res, req)
+
+	if res.Code != 202 || res.Body.String() != "partial" {
+		t.Errorf("%d %q, want the started response left alone", res.Code, res.Body.String())
+	}
+}
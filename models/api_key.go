@@ -0,0 +1,46 @@
+package models
+
+import (
+	"regexp"
+	"time"
+
+	goar "github.com/obieq/goar"
+)
+
+// Roles => what an api key may do, as granted by the authorization policy (see auth.Policy)
+const (
+	READER_ROLE string = "reader"
+	EDITOR_ROLE string = "editor"
+	ADMIN_ROLE  string = "admin"
+)
+
+var rolePattern = regexp.MustCompile("^(" + READER_ROLE + "|" + EDITOR_ROLE + "|" + ADMIN_ROLE + ")$")
+
+// APIKey => credential for the HTTP API; only a hash of its secret is stored (see auth.Issue)
+type APIKey struct {
+	BaseModel
+	Name      string     `json:"name,omitempty"`
+	Hash      string     `json:"hash,omitempty"`
+	Role      string     `json:"role,omitempty"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+func (model APIKey) ToActiveRecord() *APIKey {
+	return goar.ToAR(&model).(*APIKey)
+}
+
+// CustomModelName => goar would derive "a_p_i_keys" from the struct name
+func (m *APIKey) CustomModelName() string {
+	return "api_keys"
+}
+
+func (m *APIKey) Validate() {
+	m.Validation.Required("Name", m.Name)
+	m.Validation.Required("Hash", m.Hash)
+	m.Validation.Match("Role", m.Role, rolePattern).Message("must be one of %s, %s or %s", READER_ROLE, EDITOR_ROLE, ADMIN_ROLE)
+}
+
+// Revoked => whether the key may no longer be used
+func (m *APIKey) Revoked() bool {
+	return m.RevokedAt != nil
+}
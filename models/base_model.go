@@ -1,12 +1,13 @@
 package models
 
 import (
-	aro "github.com/obieq/goar/db/orchestrate"
+	"github.com/obieq/rva-devops-api/db"
 	"github.com/twinj/uuid"
 )
 
+// BaseModel => persists through whichever storage backend was selected at startup (see db.Use)
 type BaseModel struct {
-	aro.ArOrchestrate
+	db.ArBackend
 }
 
 func (m *BaseModel) BeforeSave() error {
@@ -0,0 +1,19 @@
+package models
+
+import goar "github.com/obieq/goar"
+
+type Owner struct {
+	BaseModel
+	FirstName string `json:"first_name,omitempty"`
+	LastName  string `json:"last_name,omitempty"`
+	Email     string `json:"email,omitempty"`
+}
+
+func (model Owner) ToActiveRecord() *Owner {
+	return goar.ToAR(&model).(*Owner)
+}
+
+func (m *Owner) Validate() {
+	m.Validation.Required("FirstName", m.FirstName)
+	m.Validation.Required("LastName", m.LastName)
+}
@@ -0,0 +1,15 @@
+package resources
+
+import "time"
+
+const API_KEY_RESOURCE_TYPE string = "api-keys"
+
+// APIKey resource
+// NOTE: Token is only ever populated in the response that issues the key; the hash is never exposed
+type APIKey struct {
+	BaseResource
+	Name      string     `json:"name,omitempty"`
+	Role      string     `json:"role,omitempty"`
+	Token     string     `json:"token,omitempty"`
+	RevokedAt *time.Time `json:"revoked-at,omitempty"`
+}
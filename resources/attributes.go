@@ -0,0 +1,54 @@
+package resources
+
+import "reflect"
+
+// ModelKey => where a resource attribute lives on the model
+type ModelKey struct {
+	// Name => the model's JSON key, as stored by the backend
+	Name string
+	// Type => the model field's type
+	Type reflect.Type
+}
+
+// AttributeKeys => maps a resource's JSON attribute names (e.g. "created-at") to the model's JSON keys (e.g. "created_at")
+// NOTE: resource and model fields are paired by Go field name; embedded structs are searched too
+func AttributeKeys(resource, model interface{}) map[string]ModelKey {
+	modelFields := jsonFields(reflect.TypeOf(model))
+	keys := make(map[string]ModelKey)
+
+	for goName, attribute := range jsonFields(reflect.TypeOf(resource)) {
+		if m, ok := modelFields[goName]; ok {
+			keys[attribute.Name] = m
+		}
+	}
+
+	return keys
+}
+
+// jsonFields => exported fields of a struct (and its embedded structs), keyed by Go field name
+func jsonFields(t reflect.Type) map[string]ModelKey {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	fields := make(map[string]ModelKey)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+
+		if f.Anonymous && f.Type.Kind() == reflect.Struct {
+			for goName, key := range jsonFields(f.Type) {
+				if _, shadowed := fields[goName]; !shadowed {
+					fields[goName] = key
+				}
+			}
+			continue
+		}
+		if f.PkgPath != "" || jsonName(f) == "-" {
+			continue
+		}
+
+		fields[f.Name] = ModelKey{Name: jsonName(f), Type: f.Type}
+	}
+
+	return fields
+}
@@ -1,26 +1,47 @@
 package resources
 
 import (
+	"encoding/json"
 	"strings"
 	"time"
 
-	as "github.com/obieq/goar/active_support"
 	resources "github.com/obieq/goar/validations"
 )
 
-const API_PATH string = "http://192.168.1.8:4000/api/v1"
+// API_PATH => path prefix shared by every resource's route
+const API_PATH string = "/api/v1"
+
+// APIRoot => absolute URL of the API, e.g. "https://api.example.com/api/v1", used to build links
+type APIRoot string
+
+// NewAPIRoot => joins a public base URL (scheme + host) with API_PATH
+func NewAPIRoot(baseURL string) APIRoot {
+	return APIRoot(strings.TrimRight(baseURL, "/") + API_PATH)
+}
+
+// Join => appends path segments to the root
+func (root APIRoot) Join(segments ...string) string {
+	return string(root) + "/" + strings.Join(segments, "/")
+}
 
 type JsonApiResourcer interface {
 	Resourcer
-	BuildLinks(model interface{})
+	BuildLinks(root APIRoot)
 	SelfLink() string
 }
 
+// Resourcer => implemented by every struct that embeds BaseResource
 type Resourcer interface {
+	Base() *BaseResource
+	Errors() []Error
+	SetErrors(map[string]*resources.ValidationError)
+}
+
+// ModelMapper => optional; resources that need custom mapping implement it instead of relying on
+// MapFromModel / MapToModel pairing fields by name
+type ModelMapper interface {
 	MapToModel(model interface{})
 	MapFromModel(model interface{})
-	Errors() map[string]string
-	SetErrors(map[string]*resources.ValidationError)
 }
 
 type Link struct {
@@ -41,23 +62,68 @@ type Linkage struct {
 	ID   string `json:"id"`
 }
 
+// Relationship => JSON API relationship object
+// NOTE: Data is omitted when the linkage was not loaded; SetLinkage renders an empty to-one as null
+type Relationship struct {
+	Links *Link            `json:"links,omitempty"`
+	Data  *json.RawMessage `json:"data,omitempty"`
+}
+
+// NewRelationship => relationship object linking to root/type/id/relationships/name and root/type/id/name
+func NewRelationship(root APIRoot, resourceType, id, name string) *Relationship {
+	return &Relationship{Links: &Link{
+		Self:    root.Join(resourceType, id, "relationships", name),
+		Related: root.Join(resourceType, id, name),
+	}}
+}
+
+// SetLinkage => sets the resource linkage: a single Linkage (or nil) for to-one, all of them for to-many
+func (rel *Relationship) SetLinkage(linkages []Linkage, toMany bool) {
+	var data interface{}
+
+	switch {
+	case toMany && linkages == nil:
+		data = []Linkage{}
+	case toMany:
+		data = linkages
+	case len(linkages) > 0:
+		data = linkages[0]
+	}
+
+	raw, _ := json.Marshal(data)
+	rel.Data = (*json.RawMessage)(&raw)
+}
+
 type BaseResource struct {
-	ResourceType string     `json:"type,omitempty"`
-	ID           string     `json:"id,omitempty"`
-	CreatedAt    *time.Time `json:"created-at,omitempty"`
-	UpdatedAt    *time.Time `json:"updated-at,omitempty"`
-	errors       map[string]*resources.ValidationError
+	ResourceType  string                   `json:"type,omitempty"`
+	ID            string                   `json:"id,omitempty"`
+	CreatedAt     *time.Time               `json:"created-at,omitempty"`
+	UpdatedAt     *time.Time               `json:"updated-at,omitempty"`
+	Relationships map[string]*Relationship `json:"relationships,omitempty"`
+	Links         *Link                    `json:"links,omitempty"`
+	errors        map[string]*resources.ValidationError
 }
 
-func (r *BaseResource) Errors() map[string]string {
-	errors := make(map[string]string)
+// Base => the embedded BaseResource, for code that handles resources generically
+func (r *BaseResource) Base() *BaseResource {
+	return r
+}
 
-	for k, v := range r.errors {
-		key := as.String(k).Dasherize()
-		errors[key] = strings.ToLower(v.Message)
+// BuildLinks => builds JSON API links
+func (r *BaseResource) BuildLinks(root APIRoot) {
+	r.Links = &Link{Self: root.Join(r.ResourceType, r.ID)}
+}
+
+func (r *BaseResource) SelfLink() string {
+	if r.Links == nil {
+		return ""
 	}
+	return r.Links.Self
+}
 
-	return errors
+// Errors => validation errors as JSON API error objects
+func (r *BaseResource) Errors() []Error {
+	return ValidationErrors(r.errors)
 }
 
 func (r *BaseResource) SetErrors(errors map[string]*resources.ValidationError) {
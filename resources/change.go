@@ -0,0 +1,168 @@
+package resources
+
+import (
+	"encoding/json"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const CHANGE_RESOURCE_TYPE string = "changes"
+
+// History size limits; Orchestrate returns at most 100 events per request
+const (
+	DEFAULT_HISTORY_SIZE int = 10
+	MAX_HISTORY_SIZE     int = 100
+)
+
+// Change resource => an entry of a resource's history (see db.Change); created-at is when it was recorded
+// NOTE: Before and After hold the resource's attributes as they were, not the stored model
+type Change struct {
+	BaseResource
+	Action    string           `json:"action,omitempty"`
+	Principal string           `json:"principal,omitempty"`
+	KeyID     string           `json:"key-id,omitempty"`
+	Before    *json.RawMessage `json:"before,omitempty"`
+	After     *json.RawMessage `json:"after,omitempty"`
+}
+
+// ChangeCursor => position of a change in a history, written as the change's id, "<milliseconds>-<ordinal>"
+// NOTE: the ordinal tells apart changes recorded in the same millisecond; within one, later changes are taken
+// to have greater ordinals
+type ChangeCursor struct {
+	Millis  int64
+	Ordinal uint64
+}
+
+// NewChangeCursor => the cursor of a change recorded at t with ordinal
+func NewChangeCursor(t time.Time, ordinal uint64) ChangeCursor {
+	return ChangeCursor{Millis: t.UnixNano() / int64(time.Millisecond), Ordinal: ordinal}
+}
+
+// ParseChangeCursor => reads a change id; ok is false if it is malformed
+func ParseChangeCursor(id string) (c ChangeCursor, ok bool) {
+	i := strings.Index(id, "-")
+	if i < 0 {
+		return c, false
+	}
+	millis, err := strconv.ParseInt(id[:i], 10, 64)
+	if err != nil || millis < 0 {
+		return c, false
+	}
+	ordinal, err := strconv.ParseUint(id[i+1:], 10, 64)
+	if err != nil {
+		return c, false
+	}
+	return ChangeCursor{Millis: millis, Ordinal: ordinal}, true
+}
+
+func (c ChangeCursor) String() string {
+	return strconv.FormatInt(c.Millis, 10) + "-" + strconv.FormatUint(c.Ordinal, 10)
+}
+
+// Time => the millisecond the change was recorded in
+func (c ChangeCursor) Time() time.Time {
+	return time.Unix(0, c.Millis*int64(time.Millisecond)).UTC()
+}
+
+// Before => whether the change was recorded before other, i.e. comes after it in a history, newest first
+func (c ChangeCursor) Before(other ChangeCursor) bool {
+	return c.Millis < other.Millis || c.Millis == other.Millis && c.Ordinal < other.Ordinal
+}
+
+// HistoryRange => filter[since] (inclusive) and filter[until] (exclusive), as RFC 3339 times, plus page[size]
+// and page[before], the id of the last change of the previous page
+type HistoryRange struct {
+	Since  time.Time
+	Until  time.Time
+	Size   int
+	Cursor *ChangeCursor
+}
+
+// ParseHistoryRange => reads filter[since], filter[until], page[size] and page[before]; the range defaults to
+// everything up to now
+func ParseHistoryRange(query url.Values, now time.Time) (HistoryRange, error) {
+	h := HistoryRange{Since: time.Unix(0, 0).UTC(), Until: now, Size: DEFAULT_HISTORY_SIZE}
+
+	for _, p := range []struct {
+		name string
+		t    *time.Time
+	}{{"filter[since]", &h.Since}, {"filter[until]", &h.Until}} {
+		if s := query.Get(p.name); s != "" {
+			t, err := time.Parse(time.RFC3339Nano, s)
+			if err != nil {
+				return h, &ParameterError{Parameter: p.name, Message: "must be an RFC 3339 time, e.g. 2015-06-01T00:00:00Z"}
+			}
+			*p.t = t
+		}
+	}
+	if !h.Since.Before(h.Until) {
+		return h, &ParameterError{Parameter: "filter[since]", Message: "must be before filter[until]"}
+	}
+
+	if s := query.Get("page[size]"); s != "" {
+		size, err := strconv.Atoi(s)
+		if err != nil || size < 1 || size > MAX_HISTORY_SIZE {
+			return h, &ParameterError{Parameter: "page[size]", Message: "must be an integer between 1 and " + strconv.Itoa(MAX_HISTORY_SIZE)}
+		}
+		h.Size = size
+	}
+
+	if s := query.Get("page[before]"); s != "" {
+		cursor, ok := ParseChangeCursor(s)
+		if !ok {
+			return h, &ParameterError{Parameter: "page[before]", Message: "must be the id of a change, e.g. 1433116800000-3"}
+		}
+		if h = h.Before(cursor); !h.Since.Before(h.Until) {
+			return h, &ParameterError{Parameter: "page[before]", Message: "must be a change recorded after filter[since]"}
+		}
+	}
+
+	return h, nil
+}
+
+// Before => the range narrowed to the changes recorded before c
+// NOTE: changes recorded in c's millisecond are fetched too, and those not before c dropped (see Includes)
+func (h HistoryRange) Before(c ChangeCursor) HistoryRange {
+	if end := c.Time().Add(time.Millisecond); end.Before(h.Until) {
+		h.Until = end
+	}
+	h.Cursor = &c
+	return h
+}
+
+// Limit => how many changes to fetch for the page; with a cursor, enough to skip the changes recorded in the
+// cursor's millisecond that the previous page already held
+func (h HistoryRange) Limit() int {
+	if h.Cursor != nil {
+		return MAX_HISTORY_SIZE
+	}
+	return h.Size
+}
+
+// Includes => whether the change belongs on the page, i.e. comes after the cursor, if any
+func (h HistoryRange) Includes(c ChangeCursor) bool {
+	return h.Cursor == nil || c.Before(*h.Cursor)
+}
+
+// Links => self link and, when there may be more changes, a next link to the changes recorded before last, the
+// page's last change
+func (h HistoryRange) Links(historyURL string, query url.Values, more bool, last ChangeCursor) PaginationLinks {
+	links := PaginationLinks{Self: historyURL}
+	if len(query) > 0 {
+		links.Self += "?" + query.Encode()
+	}
+
+	if more {
+		q := url.Values{}
+		for k, v := range query {
+			q[k] = v
+		}
+		q.Set("page[size]", strconv.Itoa(h.Size))
+		q.Set("page[before]", last.String())
+		links.Next = historyURL + "?" + q.Encode()
+	}
+
+	return links
+}
@@ -0,0 +1,117 @@
+package resources
+
+import (
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestParseChangeCursor(t *testing.T) {
+	tests := []struct {
+		id   string
+		want ChangeCursor
+		ok   bool
+	}{
+		{"1433116800000-3", ChangeCursor{Millis: 1433116800000, Ordinal: 3}, true},
+		{"0-0", ChangeCursor{}, true},
+		{"1433116800000", ChangeCursor{}, false},
+		{"-1-3", ChangeCursor{}, false},
+		{"x-3", ChangeCursor{}, false},
+		{"1433116800000-x", ChangeCursor{}, false},
+	}
+
+	for _, test := range tests {
+		got, ok := ParseChangeCursor(test.id)
+		if ok != test.ok || ok && got != test.want {
+			t.Errorf("ParseChangeCursor(%q) = %+v, %v, want %+v, %v", test.id, got, ok, test.want, test.ok)
+		}
+		if ok && got.String() != test.id {
+			t.Errorf("ParseChangeCursor(%q).String() = %q", test.id, got.String())
+		}
+	}
+}
+
+func TestHistoryRangeCursor(t *testing.T) {
+	now := time.Date(2015, 6, 2, 0, 0, 0, 0, time.UTC)
+	recorded := time.Date(2015, 6, 1, 0, 0, 0, 500*int(time.Microsecond), time.UTC)
+	cursor := NewChangeCursor(recorded, 7)
+
+	h, err := ParseHistoryRange(url.Values{"page[before]": {cursor.String()}}, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the cursor's whole millisecond is fetched, so changes recorded in it after the cursor's can be kept
+	if want := time.Date(2015, 6, 1, 0, 0, 0, int(time.Millisecond), time.UTC); !h.Until.Equal(want) {
+		t.Errorf("Until = %v, want %v", h.Until, want)
+	}
+	if h.Limit() != MAX_HISTORY_SIZE {
+		t.Errorf("Limit() = %d with a cursor, want %d", h.Limit(), MAX_HISTORY_SIZE)
+	}
+
+	tests := []struct {
+		change ChangeCursor
+		want   bool
+	}{
+		{ChangeCursor{Millis: cursor.Millis, Ordinal: 8}, false},
+		{cursor, false},
+		{ChangeCursor{Millis: cursor.Millis, Ordinal: 6}, true},
+		{ChangeCursor{Millis: cursor.Millis - 1, Ordinal: 9}, true},
+	}
+	for _, test := range tests {
+		if got := h.Includes(test.change); got != test.want {
+			t.Errorf("Includes(%v) = %v, want %v", test.change, got, test.want)
+		}
+	}
+
+	// narrowing again keeps the earlier of the two ends
+	earlier := h.Before(ChangeCursor{Millis: cursor.Millis - 10, Ordinal: 1})
+	if want := time.Date(2015, 5, 31, 23, 59, 59, int(991*time.Millisecond), time.UTC); !earlier.Until.Equal(want) || earlier.Cursor.Ordinal != 1 {
+		t.Errorf("Before: Until = %v, cursor %v, want %v", earlier.Until, earlier.Cursor, want)
+	}
+	if later := earlier.Before(cursor); !later.Until.Equal(earlier.Until) {
+		t.Errorf("Before a later change: Until = %v, want %v", later.Until, earlier.Until)
+	}
+	if h.Cursor.Ordinal != 7 {
+		t.Errorf("Before changed the original range's cursor to %v", h.Cursor)
+	}
+}
+
+func TestHistoryRangeLinks(t *testing.T) {
+	h := HistoryRange{Size: DEFAULT_HISTORY_SIZE}
+	query := url.Values{"page[before]": {"5-6"}}
+
+	links := h.Links("http://example.com/history", query, true, ChangeCursor{Millis: 1, Ordinal: 2})
+	if want := "http://example.com/history?page%5Bbefore%5D=5-6"; links.Self != want {
+		t.Errorf("self link = %q, want %q", links.Self, want)
+	}
+	if want := "http://example.com/history?page%5Bbefore%5D=1-2&page%5Bsize%5D=10"; links.Next != want {
+		t.Errorf("next link = %q, want %q", links.Next, want)
+	}
+
+	if links := h.Links("http://example.com/history", nil, false, ChangeCursor{Millis: 1, Ordinal: 2}); links.Self != "http://example.com/history" || links.Next != "" {
+		t.Errorf("last page links = %+v, want only self", links)
+	}
+}
+
+func TestParseHistoryRangeErrors(t *testing.T) {
+	now := time.Date(2015, 6, 2, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		query, parameter string
+	}{
+		{"filter[since]=yesterday", "filter[since]"},
+		{"filter[since]=2015-06-02T00:00:00Z", "filter[since]"},
+		{"page[size]=0", "page[size]"},
+		{"page[size]=101", "page[size]"},
+		{"page[before]=3", "page[before]"},
+		{"filter[since]=2015-06-01T00:00:00Z&page[before]=1-2", "page[before]"},
+	}
+
+	for _, test := range tests {
+		query, _ := url.ParseQuery(test.query)
+		_, err := ParseHistoryRange(query, now)
+		if perr, ok := err.(*ParameterError); !ok || perr.Parameter != test.parameter {
+			t.Errorf("ParseHistoryRange(%q) error = %v, want a ParameterError on %s", test.query, err, test.parameter)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package resources
+
+import (
+	"net/url"
+
+	goar "github.com/obieq/goar"
+)
+
+// CollectionQuery => page, filter and sort parameters of a request for a collection
+type CollectionQuery struct {
+	Page    Page
+	Filters []goar.QueryCondition
+	Sorts   []goar.OrderBy
+	// Fields => sparse fieldset for the collection's resource type (nil means all attributes)
+	Fields []string
+	// Plucks => model keys backing Fields, for adapters that can project
+	Plucks []interface{}
+	// Values => the raw query string, preserved in pagination links
+	Values url.Values
+}
+
+// ParseCollectionQuery => parses page[...], filter[...], sort and fields[...] against a resource's attribute keys (see AttributeKeys)
+func ParseCollectionQuery(query url.Values, resourceType string, keys map[string]ModelKey) (q CollectionQuery, err error) {
+	q.Values = query
+
+	if q.Page, err = ParsePage(query); err != nil {
+		return q, err
+	}
+	if q.Filters, err = ParseFilters(query, keys); err != nil {
+		return q, err
+	}
+	if q.Sorts, err = ParseSort(query.Get("sort"), keys); err != nil {
+		return q, err
+	}
+	if q.Fields, err = ParseFieldset(query, resourceType, keys); err != nil {
+		return q, err
+	}
+	if q.Fields != nil {
+		q.Plucks = Plucks(q.Fields, keys)
+	}
+
+	return q, nil
+}
+
+// Searching => filtered or sorted collections are searched rather than listed by key
+func (q CollectionQuery) Searching() bool {
+	return len(q.Filters) > 0 || len(q.Sorts) > 0
+}
@@ -0,0 +1,69 @@
+package resources
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	as "github.com/obieq/goar/active_support"
+	"github.com/obieq/goar/validations"
+)
+
+// Error => JSON API error object (http://jsonapi.org/format/#error-objects)
+type Error struct {
+	Status string       `json:"status,omitempty"`
+	Code   string       `json:"code,omitempty"`
+	Title  string       `json:"title,omitempty"`
+	Detail string       `json:"detail,omitempty"`
+	Source *ErrorSource `json:"source,omitempty"`
+}
+
+// ErrorSource => points at the part of the request document or query string that caused an error
+type ErrorSource struct {
+	Pointer   string `json:"pointer,omitempty"`
+	Parameter string `json:"parameter,omitempty"`
+}
+
+// NewError => error object for an HTTP status; code and title are derived from the status text
+func NewError(status int, detail string) Error {
+	return Error{
+		Status: strconv.Itoa(status),
+		Code:   StatusCode(status),
+		Title:  http.StatusText(status),
+		Detail: detail,
+	}
+}
+
+// StatusCode => dasherized status text, e.g. 404 => "not-found"
+func StatusCode(status int) string {
+	return strings.ToLower(strings.Replace(http.StatusText(status), " ", "-", -1))
+}
+
+// AttributePointer => JSON pointer to a resource attribute, e.g. "/data/attributes/year"
+func AttributePointer(attribute string) string {
+	return "/data/attributes/" + attribute
+}
+
+// ValidationErrors => converts goar validation errors into 422 error objects, ordered by attribute
+func ValidationErrors(errs map[string]*validations.ValidationError) []Error {
+	keys := make([]string, 0, len(errs))
+	for k := range errs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	result := make([]Error, len(keys))
+	for i, k := range keys {
+		attribute := as.String(k).Dasherize()
+		result[i] = Error{
+			Status: strconv.Itoa(422),
+			Code:   "invalid-attribute",
+			Title:  "Invalid Attribute",
+			Detail: attribute + " " + strings.ToLower(errs[k].Message),
+			Source: &ErrorSource{Pointer: AttributePointer(attribute)},
+		}
+	}
+
+	return result
+}
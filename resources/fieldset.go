@@ -0,0 +1,75 @@
+package resources
+
+import (
+	"encoding/json"
+	"net/url"
+	"strings"
+)
+
+// alwaysSerialized => members of a resource object that a sparse fieldset never removes
+var alwaysSerialized = map[string]bool{
+	"id":            true,
+	"type":          true,
+	"links":         true,
+	"relationships": true,
+}
+
+// ParseFieldset => attributes requested via fields[resourceType]=a,b (nil means all of them)
+func ParseFieldset(query url.Values, resourceType string, keys map[string]ModelKey) ([]string, error) {
+	param := "fields[" + resourceType + "]"
+	if _, ok := query[param]; !ok {
+		return nil, nil
+	}
+
+	fields := []string{}
+	for _, field := range strings.Split(query.Get(param), ",") {
+		if field == "" {
+			continue
+		}
+		if _, ok := keys[field]; !ok {
+			return nil, &ParameterError{Parameter: param, Message: "unknown attribute " + field}
+		}
+		fields = append(fields, field)
+	}
+
+	return fields, nil
+}
+
+// Plucks => model keys to project when loading a sparse fieldset; always includes the model's id
+func Plucks(fields []string, keys map[string]ModelKey) []interface{} {
+	plucks := []interface{}{keys["id"].Name}
+	for _, field := range fields {
+		if field != "id" {
+			plucks = append(plucks, keys[field].Name)
+		}
+	}
+	return plucks
+}
+
+// Sparse => the resource with only the requested attributes; fields == nil returns it unchanged
+func Sparse(resource interface{}, fields []string) interface{} {
+	if fields == nil {
+		return resource
+	}
+
+	raw, err := json.Marshal(resource)
+	if err != nil {
+		return resource
+	}
+	members := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(raw, &members); err != nil {
+		return resource
+	}
+
+	requested := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		requested[field] = true
+	}
+	for name := range members {
+		if !alwaysSerialized[name] && !requested[name] {
+			delete(members, name)
+		}
+	}
+
+	return members
+}
@@ -0,0 +1,105 @@
+package resources
+
+import (
+	"encoding/json"
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+
+	goar "github.com/obieq/goar"
+)
+
+// filterOperators => operator suffixes accepted in filter[attribute][operator]=value
+var filterOperators = map[string]goar.EnumRelationalOperators{
+	"eq":  goar.EQ,
+	"ne":  goar.NE,
+	"lt":  goar.LT,
+	"lte": goar.LTE,
+	"gt":  goar.GT,
+	"gte": goar.GTE,
+}
+
+// ParseFilters => translates filter[attribute]=value and filter[attribute][operator]=value
+// into goar query conditions on the model's JSON keys; conditions are ANDed together
+func ParseFilters(query url.Values, keys map[string]ModelKey) ([]goar.QueryCondition, error) {
+	var params []string
+	for param := range query {
+		if strings.HasPrefix(param, "filter[") {
+			params = append(params, param)
+		}
+	}
+	sort.Strings(params) // deterministic query order
+
+	conditions := make([]goar.QueryCondition, 0, len(params))
+	for _, param := range params {
+		attribute, operator, ok := parseFilterParam(param)
+		if !ok {
+			return nil, &ParameterError{Parameter: param, Message: "must look like filter[attribute] or filter[attribute][operator]"}
+		}
+
+		key, ok := keys[attribute]
+		if !ok {
+			return nil, &ParameterError{Parameter: param, Message: "cannot filter on unknown attribute " + attribute}
+		}
+
+		op, ok := filterOperators[operator]
+		if !ok {
+			return nil, &ParameterError{Parameter: param, Message: "unknown operator " + operator + " (use eq, ne, lt, lte, gt or gte)"}
+		}
+
+		for _, raw := range query[param] {
+			value, err := filterValue(raw, key.Type)
+			if err != nil {
+				return nil, &ParameterError{Parameter: param, Message: "must be a valid " + key.Type.String()}
+			}
+
+			conditions = append(conditions, goar.QueryCondition{
+				LogicalOperator:    goar.AND,
+				Key:                key.Name,
+				RelationalOperator: op,
+				Value:              value,
+			})
+		}
+	}
+
+	return conditions, nil
+}
+
+// parseFilterParam => "filter[year][gte]" => ("year", "gte"); the operator defaults to "eq"
+func parseFilterParam(param string) (attribute, operator string, ok bool) {
+	rest := strings.TrimPrefix(param, "filter[")
+
+	end := strings.Index(rest, "]")
+	if end < 1 {
+		return "", "", false
+	}
+	attribute, rest = rest[:end], rest[end+1:]
+
+	switch {
+	case rest == "":
+		return attribute, "eq", true
+	case strings.HasPrefix(rest, "[") && strings.HasSuffix(rest, "]") && len(rest) > 2:
+		return attribute, rest[1 : len(rest)-1], true
+	default:
+		return "", "", false
+	}
+}
+
+// filterValue => converts a query string value to the model field's type (strings pass through)
+func filterValue(raw string, t reflect.Type) (interface{}, error) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.String, reflect.Struct: // structs, e.g. time.Time, compare in their string form
+		return raw, nil
+	}
+
+	v := reflect.New(t)
+	if err := json.Unmarshal([]byte(raw), v.Interface()); err != nil {
+		return nil, err
+	}
+	return v.Elem().Interface(), nil
+}
@@ -0,0 +1,87 @@
+package resources
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	goar "github.com/obieq/goar"
+)
+
+var testKeys = map[string]ModelKey{
+	"make":       {Name: "make", Type: reflect.TypeOf("")},
+	"year":       {Name: "year", Type: reflect.TypeOf(0)},
+	"insured":    {Name: "insured", Type: reflect.TypeOf(false)},
+	"created-at": {Name: "created_at", Type: reflect.TypeOf(&time.Time{})},
+	"tags":       {Name: "tags", Type: reflect.TypeOf([]string{})},
+}
+
+func TestParseFilters(t *testing.T) {
+	tests := []struct {
+		query string
+		want  []goar.QueryCondition
+	}{
+		{"", []goar.QueryCondition{}},
+		{"sort=year&page[size]=5", []goar.QueryCondition{}},
+		{"filter[make]=Ford", []goar.QueryCondition{
+			{LogicalOperator: goar.AND, Key: "make", RelationalOperator: goar.EQ, Value: "Ford"},
+		}},
+		{"filter[make][ne]=Ford+Motor", []goar.QueryCondition{
+			{LogicalOperator: goar.AND, Key: "make", RelationalOperator: goar.NE, Value: "Ford Motor"},
+		}},
+		// values take the model field's type, and conditions are ordered by parameter
+		{"filter[year][lt]=2010&filter[year][gte]=2000", []goar.QueryCondition{
+			{LogicalOperator: goar.AND, Key: "year", RelationalOperator: goar.GTE, Value: 2000},
+			{LogicalOperator: goar.AND, Key: "year", RelationalOperator: goar.LT, Value: 2010},
+		}},
+		{"filter[insured]=true", []goar.QueryCondition{
+			{LogicalOperator: goar.AND, Key: "insured", RelationalOperator: goar.EQ, Value: true},
+		}},
+		// timestamps compare in their string form, under the model's JSON key
+		{"filter[created-at][gt]=2015-06-01T00:00:00Z", []goar.QueryCondition{
+			{LogicalOperator: goar.AND, Key: "created_at", RelationalOperator: goar.GT, Value: "2015-06-01T00:00:00Z"},
+		}},
+		// a repeated parameter adds a condition per value
+		{"filter[make]=Ford&filter[make]=Audi", []goar.QueryCondition{
+			{LogicalOperator: goar.AND, Key: "make", RelationalOperator: goar.EQ, Value: "Ford"},
+			{LogicalOperator: goar.AND, Key: "make", RelationalOperator: goar.EQ, Value: "Audi"},
+		}},
+	}
+
+	for _, test := range tests {
+		query, _ := url.ParseQuery(test.query)
+		got, err := ParseFilters(query, testKeys)
+		if err != nil {
+			t.Errorf("ParseFilters(%q) error: %v", test.query, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("ParseFilters(%q) = %+v, want %+v", test.query, got, test.want)
+		}
+	}
+}
+
+func TestParseFiltersErrors(t *testing.T) {
+	tests := []struct {
+		query, parameter string
+	}{
+		{"filter[color]=red", "filter[color]"},
+		{"filter[year][like]=20", "filter[year][like]"},
+		{"filter[year]=new", "filter[year]"},
+		{"filter[insured]=maybe", "filter[insured]"},
+		{"filter[]=x", "filter[]"},
+		{"filter[year][]=2000", "filter[year][]"},
+		{"filter[year]x=2000", "filter[year]x"},
+		{"filter[year=2000", "filter[year"},
+	}
+
+	for _, test := range tests {
+		query, _ := url.ParseQuery(test.query)
+		_, err := ParseFilters(query, testKeys)
+		perr, ok := err.(*ParameterError)
+		if !ok || perr.Parameter != test.parameter {
+			t.Errorf("ParseFilters(%q) error = %v, want a ParameterError on %s", test.query, err, test.parameter)
+		}
+	}
+}
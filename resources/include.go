@@ -0,0 +1,51 @@
+package resources
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// Include => tree of relationship paths to include, e.g. "owner,owner.automobiles" => {"owner": {"automobiles": {}}}
+type Include map[string]Include
+
+// ParseInclude => parses the include parameter; nil if absent or empty
+// NOTE: relationship names are checked by the controller, which knows the resources' relationships
+func ParseInclude(query url.Values) Include {
+	var include Include
+
+	for _, path := range strings.Split(query.Get("include"), ",") {
+		if path == "" {
+			continue
+		}
+		if include == nil {
+			include = make(Include)
+		}
+
+		node := include
+		for _, name := range strings.Split(path, ".") {
+			if node[name] == nil {
+				node[name] = make(Include)
+			}
+			node = node[name]
+		}
+	}
+
+	return include
+}
+
+// Names => the relationships included at this level, sorted so included resources are ordered predictably
+func (include Include) Names() []string {
+	names := make([]string, 0, len(include))
+	for name := range include {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Document => top-level JSON API document for a single resource, with the resources it includes
+type Document struct {
+	Data     interface{}   `json:"data"`
+	Included []interface{} `json:"included,omitempty"`
+}
@@ -0,0 +1,76 @@
+package resources
+
+import (
+	"reflect"
+	"time"
+
+	"github.com/obieq/goar"
+	"github.com/obieq/goar/validations"
+)
+
+// validated => goar models expose their validation state through the embedded validations.Validation
+type validated interface {
+	HasErrors() bool
+	ErrorMap() map[string]*validations.ValidationError
+}
+
+// MapFromModel => copies a goar model's id, timestamps and attributes into a resource
+// NOTE: attributes are paired by Go field name; a model with validation errors only copies its errors
+func MapFromModel(resourceType string, resource JsonApiResourcer, model interface{}) {
+	if v, ok := model.(validated); ok && v.HasErrors() {
+		resource.SetErrors(v.ErrorMap())
+		return
+	}
+
+	base := resource.Base()
+	base.ResourceType = resourceType
+
+	if mapper, ok := resource.(ModelMapper); ok {
+		mapper.MapFromModel(model)
+		return
+	}
+
+	m := reflect.Indirect(reflect.ValueOf(model))
+	if f := m.FieldByName("ID"); f.IsValid() {
+		base.ID = f.String()
+	}
+	if f := m.FieldByName("CreatedAt"); f.IsValid() {
+		base.CreatedAt, _ = f.Interface().(*time.Time)
+	}
+	if f := m.FieldByName("UpdatedAt"); f.IsValid() {
+		base.UpdatedAt, _ = f.Interface().(*time.Time)
+	}
+
+	r := reflect.ValueOf(resource).Elem()
+	for _, f := range ownFields(r.Type()) {
+		copyField(r.FieldByIndex(f.Index), m.FieldByName(f.Name))
+	}
+}
+
+// MapToModel => copies a resource's attributes into a goar model; the id is only copied onto new models
+func MapToModel(resource JsonApiResourcer, model goar.ActiveRecordInterfacer) {
+	if mapper, ok := resource.(ModelMapper); ok {
+		mapper.MapToModel(model)
+	} else {
+		m := reflect.ValueOf(model).Elem()
+		r := reflect.ValueOf(resource).Elem()
+
+		for _, f := range ownFields(r.Type()) {
+			copyField(m.FieldByName(f.Name), r.FieldByIndex(f.Index))
+		}
+
+		if f := m.FieldByName("CreatedAt"); f.IsValid() && f.IsNil() { // we're inserting a new record
+			model.SetKey(resource.Base().ID)
+		}
+	}
+
+	// convert model to an active record model
+	goar.ToAR(model)
+}
+
+// copyField => assigns src to dst when both exist and have the same type
+func copyField(dst, src reflect.Value) {
+	if dst.IsValid() && src.IsValid() && dst.CanSet() && src.Type().AssignableTo(dst.Type()) {
+		dst.Set(src)
+	}
+}
@@ -0,0 +1,12 @@
+package resources
+
+const OWNER_RESOURCE_TYPE string = "owners"
+
+// Owner resource
+// NOTE: relates to the automobiles it owns (see api.BelongsTo)
+type Owner struct {
+	BaseResource
+	FirstName string `json:"first-name,omitempty"`
+	LastName  string `json:"last-name,omitempty"`
+	Email     string `json:"email,omitempty"`
+}
@@ -0,0 +1,158 @@
+package resources
+
+import (
+	"net/url"
+	"strconv"
+
+	"github.com/obieq/rva-devops-api/db"
+)
+
+// Page size limits; Orchestrate lists at most 100 items per request
+const (
+	DEFAULT_PAGE_SIZE int = 10
+	MAX_PAGE_SIZE     int = 100
+)
+
+// ParameterError => a query string parameter that is unknown or malformed
+type ParameterError struct {
+	Parameter string
+	Message   string
+}
+
+func (e *ParameterError) Error() string {
+	return e.Parameter + ": " + e.Message
+}
+
+// Page => page[size] plus either page[after] (listing by key) or page[offset] (searching)
+type Page struct {
+	Size   int
+	After  string
+	Offset int
+}
+
+// ParsePage => reads page[size], page[after] and page[offset], applying defaults and limits
+func ParsePage(query url.Values) (Page, error) {
+	page := Page{Size: DEFAULT_PAGE_SIZE, After: query.Get("page[after]")}
+
+	if s := query.Get("page[offset]"); s != "" {
+		offset, err := strconv.Atoi(s)
+		if err != nil || offset < 0 {
+			return page, &ParameterError{Parameter: "page[offset]", Message: "must be a non-negative integer"}
+		}
+		if page.After != "" {
+			return page, &ParameterError{Parameter: "page[offset]", Message: "cannot be combined with page[after]"}
+		}
+		page.Offset = offset
+	}
+
+	if s := query.Get("page[size]"); s != "" {
+		size, err := strconv.Atoi(s)
+		if err != nil || size < 1 || size > MAX_PAGE_SIZE {
+			return page, &ParameterError{Parameter: "page[size]", Message: "must be an integer between 1 and " + strconv.Itoa(MAX_PAGE_SIZE)}
+		}
+		page.Size = size
+	}
+
+	return page, nil
+}
+
+// Options => goar All options for this page
+func (p Page) Options() map[string]interface{} {
+	opts := map[string]interface{}{"limit": p.Size}
+	if p.After != "" {
+		opts["afterKey"] = p.After
+	}
+	return opts
+}
+
+// Meta => top-level meta describing this page
+func (p Page) Meta() map[string]interface{} {
+	return map[string]interface{}{"page": map[string]interface{}{"size": p.Size}}
+}
+
+// Links => self/first/prev/next links; any other query parameters (filters etc.) are preserved
+func (p Page) Links(collectionURL string, query url.Values, cursors db.Page) PaginationLinks {
+	links := PaginationLinks{
+		Self:  p.link(collectionURL, query, p.After, p.After != ""),
+		First: p.link(collectionURL, query, "", false),
+	}
+
+	if cursors.NextKey != "" {
+		links.Next = p.link(collectionURL, query, cursors.NextKey, true)
+	}
+	if cursors.HasPrev {
+		links.Prev = p.link(collectionURL, query, cursors.PrevKey, cursors.PrevKey != "")
+	}
+
+	return links
+}
+
+// OffsetLinks => self/first/prev/next links for search results paged by page[offset]
+// NOTE: a full page (count == size) is assumed to have a successor
+func (p Page) OffsetLinks(collectionURL string, query url.Values, count int) PaginationLinks {
+	links := PaginationLinks{
+		Self:  p.offsetLink(collectionURL, query, p.Offset),
+		First: p.offsetLink(collectionURL, query, 0),
+	}
+
+	if count >= p.Size {
+		links.Next = p.offsetLink(collectionURL, query, p.Offset+p.Size)
+	}
+	if p.Offset > 0 {
+		prev := p.Offset - p.Size
+		if prev < 0 {
+			prev = 0
+		}
+		links.Prev = p.offsetLink(collectionURL, query, prev)
+	}
+
+	return links
+}
+
+func (p Page) link(collectionURL string, query url.Values, after string, hasAfter bool) string {
+	q := p.baseQuery(query)
+	if hasAfter {
+		q.Set("page[after]", after)
+	}
+
+	return collectionURL + "?" + q.Encode()
+}
+
+func (p Page) offsetLink(collectionURL string, query url.Values, offset int) string {
+	q := p.baseQuery(query)
+	if offset > 0 {
+		q.Set("page[offset]", strconv.Itoa(offset))
+	}
+
+	return collectionURL + "?" + q.Encode()
+}
+
+// baseQuery => copy of the request's query with only page[size] among the page parameters
+func (p Page) baseQuery(query url.Values) url.Values {
+	q := url.Values{}
+	for k, v := range query {
+		q[k] = v
+	}
+
+	q.Set("page[size]", strconv.Itoa(p.Size))
+	q.Del("page[after]")
+	q.Del("page[offset]")
+
+	return q
+}
+
+// PaginationLinks => JSON API top-level links for a collection
+type PaginationLinks struct {
+	Self  string `json:"self,omitempty"`
+	First string `json:"first,omitempty"`
+	Prev  string `json:"prev,omitempty"`
+	Next  string `json:"next,omitempty"`
+}
+
+// CollectionDocument => top-level JSON API document for a collection; links are serialized before data
+type CollectionDocument struct {
+	Links    PaginationLinks        `json:"links"`
+	Meta     map[string]interface{} `json:"meta,omitempty"`
+	Data     interface{}            `json:"data"`
+	Included []interface{}          `json:"included,omitempty"`
+}
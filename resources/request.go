@@ -0,0 +1,167 @@
+package resources
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/martini-contrib/binding"
+)
+
+// readOnlyMembers => members of a resource object that a PATCH may carry but never changes
+var readOnlyMembers = map[string]bool{
+	"id":            true,
+	"type":          true,
+	"links":         true,
+	"relationships": true,
+	"created-at":    true,
+	"updated-at":    true,
+}
+
+// JsonApiRequest => POST/PUT document; data is decoded into the registered resource type by the controller
+type JsonApiRequest struct {
+	Data json.RawMessage `json:"data"`
+}
+
+// Validate => implements binding.Validator
+func (req JsonApiRequest) Validate(errs binding.Errors, r *http.Request) binding.Errors {
+	if len(req.Data) == 0 || string(req.Data) == "null" {
+		errs.Add([]string{}, binding.RequiredError, "a data object is required")
+	}
+	return errs
+}
+
+// JsonApiPatchRequest => PATCH document; members are kept raw so absent and null values can be told apart
+type JsonApiPatchRequest struct {
+	Data map[string]json.RawMessage `json:"data"`
+}
+
+// Validate => implements binding.Validator
+func (req JsonApiPatchRequest) Validate(errs binding.Errors, r *http.Request) binding.Errors {
+	if req.Data == nil {
+		errs.Add([]string{}, binding.RequiredError, "a data object is required")
+	}
+	return errs
+}
+
+// JsonApiRelationshipRequest => body of a relationship endpoint: a linkage object, null, or an array of linkages
+type JsonApiRelationshipRequest struct {
+	Data json.RawMessage `json:"data"`
+}
+
+// Validate => implements binding.Validator
+func (req JsonApiRelationshipRequest) Validate(errs binding.Errors, r *http.Request) binding.Errors {
+	if len(req.Data) == 0 {
+		errs.Add([]string{}, binding.RequiredError, "a data member is required")
+	}
+	return errs
+}
+
+// Linkages => decodes data as an array of linkages (toMany) or a single linkage or null (to-one)
+func (req JsonApiRelationshipRequest) Linkages(toMany bool) ([]Linkage, error) {
+	var linkages []Linkage
+
+	if toMany {
+		if err := json.Unmarshal(req.Data, &linkages); err != nil || linkages == nil {
+			return nil, errors.New("data must be an array of resource identifiers")
+		}
+	} else if string(req.Data) != "null" {
+		var linkage Linkage
+		if err := json.Unmarshal(req.Data, &linkage); err != nil {
+			return nil, errors.New("data must be a resource identifier or null")
+		}
+		linkages = append(linkages, linkage)
+	}
+
+	for _, linkage := range linkages {
+		if linkage.Type == "" || linkage.ID == "" {
+			return nil, errors.New("resource identifiers require a type and an id")
+		}
+	}
+
+	return linkages, nil
+}
+
+// Member => unquoted string value of a top-level member such as "id" or "type", or "" if absent
+func (req JsonApiPatchRequest) Member(name string) string {
+	var s string
+	if raw, ok := req.Data[name]; ok {
+		json.Unmarshal(raw, &s)
+	}
+	return s
+}
+
+// AttributeError => a PATCH attribute that is unknown or cannot be decoded
+type AttributeError struct {
+	Attribute string
+	Message   string
+}
+
+func (e *AttributeError) Error() string {
+	return e.Attribute + ": " + e.Message
+}
+
+// PatchAttributes => applies only the attributes present in a PATCH document to a resource
+// NOTE: explicit nulls reset an attribute to its zero value; absent attributes are left untouched
+func PatchAttributes(resource interface{}, members map[string]json.RawMessage) error {
+	fields := attributeFields(reflect.ValueOf(resource).Elem())
+
+	for name, raw := range members {
+		if readOnlyMembers[name] {
+			continue
+		}
+
+		field, ok := fields[name]
+		if !ok {
+			return &AttributeError{Attribute: name, Message: "is not a known attribute"}
+		}
+
+		if string(raw) == "null" {
+			field.Set(reflect.Zero(field.Type()))
+			continue
+		}
+
+		if err := json.Unmarshal(raw, field.Addr().Interface()); err != nil {
+			return &AttributeError{Attribute: name, Message: fmt.Sprintf("must be a valid %v", field.Type())}
+		}
+	}
+
+	return nil
+}
+
+// attributeFields => a resource's own (non-embedded) fields, keyed by JSON name
+func attributeFields(v reflect.Value) map[string]reflect.Value {
+	fields := make(map[string]reflect.Value)
+
+	for _, f := range ownFields(v.Type()) {
+		fields[jsonName(f)] = v.FieldByIndex(f.Index)
+	}
+
+	return fields
+}
+
+// ownFields => exported, non-embedded struct fields that are serialized to JSON
+func ownFields(t reflect.Type) []reflect.StructField {
+	var fields []reflect.StructField
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Anonymous || f.PkgPath != "" || jsonName(f) == "-" {
+			continue
+		}
+		fields = append(fields, f)
+	}
+
+	return fields
+}
+
+// jsonName => the field's name in JSON documents
+func jsonName(f reflect.StructField) string {
+	if name := strings.Split(f.Tag.Get("json"), ",")[0]; name != "" {
+		return name
+	}
+	return f.Name
+}
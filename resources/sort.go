@@ -0,0 +1,53 @@
+package resources
+
+import (
+	"reflect"
+	"strings"
+
+	goar "github.com/obieq/goar"
+)
+
+// ParseSort => translates JSON API sort syntax (e.g. "-year,make") into goar order bys on the model's JSON keys
+func ParseSort(sort string, keys map[string]ModelKey) ([]goar.OrderBy, error) {
+	if sort == "" {
+		return nil, nil
+	}
+
+	fields := strings.Split(sort, ",")
+	orderBys := make([]goar.OrderBy, 0, len(fields))
+
+	for _, field := range fields {
+		order := goar.ASC
+		if strings.HasPrefix(field, "-") {
+			order = goar.DESC
+			field = field[1:]
+		}
+
+		key, ok := keys[field]
+		if !ok {
+			return nil, &ParameterError{Parameter: "sort", Message: "cannot sort on unknown attribute " + field}
+		}
+		if !sortable(key.Type) {
+			return nil, &ParameterError{Parameter: "sort", Message: "cannot sort on attribute " + field}
+		}
+
+		orderBys = append(orderBys, goar.OrderBy{Key: key.Name, SortOrder: order})
+	}
+
+	return orderBys, nil
+}
+
+// sortable => scalars (and timestamps) can be ordered; collections and objects cannot
+func sortable(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Array, reflect.Slice, reflect.Map, reflect.Interface, reflect.Func, reflect.Chan:
+		return false
+	case reflect.Struct:
+		return t.PkgPath() == "time" && t.Name() == "Time"
+	}
+	return true
+}
@@ -0,0 +1,41 @@
+package resources
+
+import (
+	"reflect"
+	"testing"
+
+	goar "github.com/obieq/goar"
+)
+
+func TestParseSort(t *testing.T) {
+	tests := []struct {
+		sort string
+		want []goar.OrderBy
+	}{
+		{"", nil},
+		{"year", []goar.OrderBy{{Key: "year", SortOrder: goar.ASC}}},
+		{"-year,make", []goar.OrderBy{{Key: "year", SortOrder: goar.DESC}, {Key: "make", SortOrder: goar.ASC}}},
+		// attributes map to the model's JSON keys
+		{"-created-at", []goar.OrderBy{{Key: "created_at", SortOrder: goar.DESC}}},
+	}
+
+	for _, test := range tests {
+		got, err := ParseSort(test.sort, testKeys)
+		if err != nil {
+			t.Errorf("ParseSort(%q) error: %v", test.sort, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("ParseSort(%q) = %+v, want %+v", test.sort, got, test.want)
+		}
+	}
+}
+
+func TestParseSortErrors(t *testing.T) {
+	for _, sort := range []string{"color", "-color", "year,", "tags", "--year"} {
+		_, err := ParseSort(sort, testKeys)
+		if perr, ok := err.(*ParameterError); !ok || perr.Parameter != "sort" {
+			t.Errorf("ParseSort(%q) error = %v, want a ParameterError on sort", sort, err)
+		}
+	}
+}
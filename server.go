@@ -1,14 +1,28 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"log"
 	"net/http"
-	"net/http/httputil"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/go-martini/martini"
-	"github.com/martini-contrib/binding"
 	"github.com/martini-contrib/render"
-	controllers "github.com/obieq/rva-devops-api/controllers"
+	"github.com/obieq/rva-devops-api/api"
+	"github.com/obieq/rva-devops-api/auth"
+	"github.com/obieq/rva-devops-api/config"
+	"github.com/obieq/rva-devops-api/db"
+	_ "github.com/obieq/rva-devops-api/db/memory"
+	_ "github.com/obieq/rva-devops-api/db/orchestrate"
+	"github.com/obieq/rva-devops-api/health"
+	"github.com/obieq/rva-devops-api/logger"
+	"github.com/obieq/rva-devops-api/metrics"
+	"github.com/obieq/rva-devops-api/middleware"
+	"github.com/obieq/rva-devops-api/models"
 	"github.com/obieq/rva-devops-api/resources"
 	"github.com/twinj/uuid"
 )
@@ -17,27 +31,168 @@ func main() {
 	// switch the uuid format
 	uuid.SwitchFormat(uuid.CleanHyphen)
 
-	m := martini.Classic()
+	// load settings from flags, env and .env
+	cfg, err := config.Load(os.Args[1:])
+	if err != nil {
+		log.Fatal(err)
+	}
+	level, _ := logger.ParseLevel(cfg.LogLevel)
+	logger.SetLevel(level)
+
+	// connect to the storage backend
+	backend, err := db.Open(cfg.Backend, cfg.BackendOptions())
+	if err != nil {
+		log.Fatal(err)
+	}
+	db.Use(backend)
+	logger.Info("Storage backend:", cfg.Backend)
+
+	// martini.Classic without its plain-text logger, which the structured access log replaces
+	router := martini.NewRouter()
+	m := &martini.ClassicMartini{Martini: martini.New(), Router: router}
+	m.Use(middleware.AccessLog(cfg.RedactHeaders...))
+	m.Use(middleware.Metrics())
+	m.Use(middleware.Recovery())
+	m.MapTo(router, (*martini.Routes)(nil))
+	m.Action(router.Handle)
+	router.NotFound(middleware.NotFound)
+
+	// browsers on other origins, e.g. an Ember app; preflights are answered before negotiation and authentication
+	if len(cfg.CORSOrigins) > 0 {
+		cors := middleware.CORSOptions{
+			AllowOrigins:     cfg.CORSOrigins,
+			AllowMethods:     cfg.CORSMethods,
+			AllowHeaders:     cfg.CORSHeaders,
+			ExposeHeaders:    cfg.CORSExposeHeaders,
+			AllowCredentials: cfg.CORSCredentials,
+			MaxAge:           cfg.CORSMaxAge,
+		}
+		if cors.AllowHeaders == nil {
+			cors.AllowHeaders = middleware.DEFAULT_CORS_ALLOW_HEADERS
+		}
+		if cors.ExposeHeaders == nil {
+			cors.ExposeHeaders = middleware.DEFAULT_CORS_EXPOSE_HEADERS
+		}
+		m.Use(middleware.CORS(cors))
+	}
+
+	// JSON API content negotiation; wraps the response writer, so it must precede the renderer
+	m.Use(middleware.MediaTypes(middleware.JsonApiMediaType{}))
 
 	// use render contrib library within controllers
 	m.Use(render.Renderer())
 
-	m.Use(func(req *http.Request) {
-		log.Println("CALLING HTTP REQUEST INTERCEPTOR!")
-		dump, _ := httputil.DumpRequest(req, true)
-		log.Println(string(dump))
-	})
+	// links are built from the configured base url or, if unset, the request's host
+	m.Use(middleware.APIRoot(cfg.BaseURL))
+
+	// probes and scrapes are exempt from rate limits, so a busy orchestrator or Prometheus never sees a 429
+	probes := middleware.RateLimits{{Route: "/healthz"}, {Route: "/readyz"}, {Route: "/metrics"}}
+
+	// each client IP gets a token bucket before authentication, which bounds guessing api keys as well
+	ipLimit, err := middleware.ParseRateLimit(cfg.IPRateLimit)
+	if err != nil {
+		log.Fatal(err)
+	}
+	m.Use(middleware.RateLimiter(append(probes, ipLimit)))
+
+	// every API request must carry an api key; handlers can ask for the caller's *auth.Principal
+	if cfg.AuthDisabled {
+		logger.Warn("Authentication is disabled")
+		m.Map(&auth.Principal{Name: "anonymous", Role: models.ADMIN_ROLE})
+	} else {
+		auth.SetAdminKey(cfg.AdminAPIKey)
+		m.Use(middleware.Authenticate())
+	}
+
+	// each api key (or client IP) gets a token bucket per route limit
+	limit, err := middleware.ParseRateLimit(cfg.RateLimit)
+	if err != nil {
+		log.Fatal(err)
+	}
+	var limits middleware.RateLimits
+	if cfg.RateLimitFile != "" {
+		if limits, err = middleware.LoadRateLimits(cfg.RateLimitFile); err != nil {
+			log.Fatal(err)
+		}
+	}
+	limits = append(probes, limits...)
+	m.Use(middleware.RateLimiter(append(limits, limit)))
+
+	// the caller's role must be granted the route by the policy
+	policy := auth.DefaultPolicy
+	if cfg.PolicyFile != "" {
+		if policy, err = auth.LoadPolicy(cfg.PolicyFile); err != nil {
+			log.Fatal(err)
+		}
+		logger.Info("Authorization policy:", cfg.PolicyFile)
+	}
+	m.Use(middleware.Authorize(policy))
 
 	m.Get("/", func() string {
 		return "Hello world!"
 	})
 
-	// quote intent routes
-	m.Get("/api/v1/automobiles", controllers.HandleGetAutomobiles)
-	m.Get("/api/v1/automobiles/:id", controllers.HandleGetAutomobile)
-	m.Post("/api/v1/automobiles", binding.Json(resources.AutomobileJsonApiRequest{}), controllers.HandleCreateAutomobile)
-	m.Put("/api/v1/automobiles/:id", binding.Json(resources.AutomobileJsonApiRequest{}), controllers.HandleUpdateAutomobile)
-	m.Delete("/api/v1/automobiles/:id", controllers.HandleDeleteAutomobile)
+	// probes: /healthz while the process serves requests, /readyz while the storage backend is reachable too
+	health.Register("storage", db.Ping)
+	m.Get("/healthz", health.Live)
+	m.Get("/readyz", health.Ready)
+
+	// Prometheus scrapes
+	m.Get("/metrics", metrics.Handler)
+
+	// resource routes
+	automobiles := api.Register(m, resources.AUTOMOBILE_RESOURCE_TYPE, models.Automobile{}, resources.Automobile{})
+	owners := api.Register(m, resources.OWNER_RESOURCE_TYPE, models.Owner{}, resources.Owner{})
+	api.BelongsTo(m, automobiles, "owner", owners, resources.AUTOMOBILE_RESOURCE_TYPE)
+	api.RegisterAPIKeys(m)
+
+	// serve until SIGTERM/SIGINT, then drain in-flight requests before closing the backend
+	err = serve(cfg, m)
+	if cerr := backend.Close(); cerr != nil {
+		logger.Error("Closing the storage backend:", cerr)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	logger.Info("Stopped")
+}
+
+// serve => listens on cfg.Addr until SIGTERM or SIGINT, then fails readiness, keeps serving for
+// cfg.ShutdownDelay, stops accepting connections and waits up to cfg.ShutdownTimeout for in-flight requests
+// NOTE: a second signal during the shutdown stops the process right away
+func serve(cfg *config.Config, handler http.Handler) error {
+	server := &http.Server{
+		Addr:         cfg.Addr,
+		Handler:      handler,
+		ReadTimeout:  cfg.ReadTimeout,
+		WriteTimeout: cfg.WriteTimeout,
+		IdleTimeout:  cfg.IdleTimeout,
+	}
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- server.ListenAndServe()
+	}()
+	logger.Info("Listening on", cfg.Addr)
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM, os.Interrupt)
+	select {
+	case err := <-errs:
+		return err
+	case sig := <-signals:
+		logger.Info("Received", sig, "- shutting down")
+	}
+	signal.Stop(signals)
+
+	health.SetDraining(true)
+	time.Sleep(cfg.ShutdownDelay)
 
-	m.RunOnAddr(":5000")
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		server.Close()
+		return fmt.Errorf("requests still in flight after %v were cut off: %v", cfg.ShutdownTimeout, err)
+	}
+	return nil
 }